@@ -0,0 +1,187 @@
+// Command proxy-inventory is a thin CLI wrapper over the Inventory mutation
+// API in the parent package, for trimming tracking beacons, patching a CSP,
+// or splicing two recordings together without hand-editing JSON.
+//
+// Usage (flags always come after the positional <dir>, not before it):
+//
+//	proxy-inventory list <dir>
+//	proxy-inventory rm <dir> [-method METHOD] [-url PATTERN]
+//	proxy-inventory rewrite-url <dir> <from> <to>
+//	proxy-inventory set-header <dir> <name> <value> [-method METHOD] [-url PATTERN]
+//	proxy-inventory merge <dst-dir> <src-dir>
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	proxy "github.com/pagespeed-quest/http-playback-proxy/golang"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = runList(os.Args[2:])
+	case "rm":
+		err = runRm(os.Args[2:])
+	case "rewrite-url":
+		err = runRewriteURL(os.Args[2:])
+	case "set-header":
+		err = runSetHeader(os.Args[2:])
+	case "merge":
+		err = runMerge(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "proxy-inventory:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: proxy-inventory <list|rm|rewrite-url|set-header|merge> ...")
+}
+
+// matcherFlags adds the -method/-url flags shared by the rm and set-header
+// subcommands and builds the ResourceMatcher they describe.
+func matcherFlags(fs *flag.FlagSet) func() (proxy.ResourceMatcher, error) {
+	method := fs.String("method", "", "only match this HTTP method")
+	urlPattern := fs.String("url", "", "only match URLs against this regular expression")
+	return func() (proxy.ResourceMatcher, error) {
+		return proxy.NewResourceMatcher(*method, *urlPattern)
+	}
+}
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: proxy-inventory list <dir>")
+	}
+	dir := fs.Arg(0)
+
+	inv, err := proxy.LoadInventory(proxy.GetInventoryPath(dir))
+	if err != nil {
+		return fmt.Errorf("failed to load inventory: %w", err)
+	}
+
+	for _, r := range inv.Resources {
+		status := "-"
+		if r.StatusCode != nil {
+			status = fmt.Sprintf("%d", *r.StatusCode)
+		}
+		fmt.Printf("%s\t%s\t%s\n", r.Method, status, r.URL)
+	}
+	return nil
+}
+
+func runRm(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: proxy-inventory rm <dir> [-method METHOD] [-url PATTERN]")
+	}
+	dir := args[0]
+
+	fs := flag.NewFlagSet("rm", flag.ExitOnError)
+	matcher := matcherFlags(fs)
+	fs.Parse(args[1:])
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: proxy-inventory rm <dir> [-method METHOD] [-url PATTERN]")
+	}
+
+	m, err := matcher()
+	if err != nil {
+		return err
+	}
+
+	path := proxy.GetInventoryPath(dir)
+	inv, err := proxy.LoadInventory(path)
+	if err != nil {
+		return fmt.Errorf("failed to load inventory: %w", err)
+	}
+
+	removed := inv.RemoveResource(m)
+	if err := proxy.SaveInventory(path, inv); err != nil {
+		return fmt.Errorf("failed to save inventory: %w", err)
+	}
+	fmt.Printf("removed %d resource(s)\n", removed)
+	return nil
+}
+
+func runRewriteURL(args []string) error {
+	fs := flag.NewFlagSet("rewrite-url", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 3 {
+		return fmt.Errorf("usage: proxy-inventory rewrite-url <dir> <from> <to>")
+	}
+	dir, from, to := fs.Arg(0), fs.Arg(1), fs.Arg(2)
+
+	path := proxy.GetInventoryPath(dir)
+	inv, err := proxy.LoadInventory(path)
+	if err != nil {
+		return fmt.Errorf("failed to load inventory: %w", err)
+	}
+
+	inv.RewriteURL(from, to)
+	return proxy.SaveInventory(path, inv)
+}
+
+func runSetHeader(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("usage: proxy-inventory set-header <dir> <name> <value> [-method METHOD] [-url PATTERN]")
+	}
+	dir, name, value := args[0], args[1], args[2]
+
+	fs := flag.NewFlagSet("set-header", flag.ExitOnError)
+	matcher := matcherFlags(fs)
+	fs.Parse(args[3:])
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: proxy-inventory set-header <dir> <name> <value> [-method METHOD] [-url PATTERN]")
+	}
+
+	m, err := matcher()
+	if err != nil {
+		return err
+	}
+
+	path := proxy.GetInventoryPath(dir)
+	inv, err := proxy.LoadInventory(path)
+	if err != nil {
+		return fmt.Errorf("failed to load inventory: %w", err)
+	}
+
+	inv.SetHeader(m, name, value)
+	return proxy.SaveInventory(path, inv)
+}
+
+func runMerge(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: proxy-inventory merge <dst-dir> <src-dir>")
+	}
+	dstDir, srcDir := fs.Arg(0), fs.Arg(1)
+
+	dst, err := proxy.LoadInventory(proxy.GetInventoryPath(dstDir))
+	if err != nil {
+		return fmt.Errorf("failed to load destination inventory: %w", err)
+	}
+	src, err := proxy.LoadInventory(proxy.GetInventoryPath(srcDir))
+	if err != nil {
+		return fmt.Errorf("failed to load source inventory: %w", err)
+	}
+
+	if err := proxy.MergeInventories(dst, src, srcDir); err != nil {
+		return fmt.Errorf("failed to merge inventories: %w", err)
+	}
+	return proxy.SaveInventory(proxy.GetInventoryPath(dstDir), dst)
+}
@@ -0,0 +1,152 @@
+package httpplaybackproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrControlEndpointNotSupported is returned by the Status/Stats/etc. client
+// methods when the running proxy binary doesn't implement the requested
+// control-port endpoint yet (HTTP 404). Callers embedding the proxy in a
+// test harness should treat this as "not available on this binary version"
+// rather than a hard failure.
+var ErrControlEndpointNotSupported = errors.New("proxy control endpoint not supported by this binary")
+
+// Status is the response of the control API's /_status endpoint.
+type Status struct {
+	Mode         ProxyMode     `json:"mode"`
+	Port         int           `json:"port"`
+	Uptime       time.Duration `json:"-"`
+	UptimeMs     uint64        `json:"uptimeMs"`
+	RequestCount uint64        `json:"requestCount"`
+	Paused       bool          `json:"paused"`
+}
+
+// Stats is the response of the control API's /_stats endpoint.
+type Stats struct {
+	RequestCount uint64            `json:"requestCount"`
+	BytesServed  uint64            `json:"bytesServed"`
+	MissCount    uint64            `json:"missCount"`
+	StatusCounts map[string]uint64 `json:"statusCounts,omitempty"`
+}
+
+// Status queries the control API for the proxy's current mode, port,
+// uptime, and basic request counters.
+func (p *Proxy) Status() (*Status, error) {
+	var status Status
+	if err := p.controlJSON(http.MethodGet, "/_status", nil, &status); err != nil {
+		return nil, err
+	}
+	status.Uptime = time.Duration(status.UptimeMs) * time.Millisecond
+	return &status, nil
+}
+
+// Stats queries the control API for request/response counters accumulated
+// since the proxy started.
+func (p *Proxy) Stats() (*Stats, error) {
+	var stats Stats
+	if err := p.controlJSON(http.MethodGet, "/_stats", nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ReloadInventory asks a playback proxy to reload inventory.json from disk,
+// picking up mutations made with the Inventory API (AddResource,
+// RemoveResource, SetHeader, ...) without restarting the proxy.
+func (p *Proxy) ReloadInventory() error {
+	return p.controlRequest(http.MethodPost, "/_reload", nil)
+}
+
+// PauseRecording asks a recording proxy to stop appending newly observed
+// resources to the inventory while continuing to proxy traffic.
+func (p *Proxy) PauseRecording() error {
+	return p.controlRequest(http.MethodPost, "/_pause", nil)
+}
+
+// ResumeRecording reverses PauseRecording.
+func (p *Proxy) ResumeRecording() error {
+	return p.controlRequest(http.MethodPost, "/_resume", nil)
+}
+
+// Snapshot asks the proxy to write its current in-memory inventory to path
+// on disk, alongside (but without disturbing) the inventory it was started
+// with. Useful for grabbing an incremental checkpoint from a long-running
+// recording session.
+func (p *Proxy) Snapshot(path string) error {
+	body, err := json.Marshal(struct {
+		Path string `json:"path"`
+	}{Path: path})
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot request: %w", err)
+	}
+	return p.controlRequest(http.MethodPost, "/_snapshot", bytes.NewReader(body))
+}
+
+// controlJSON issues a control-port request and decodes a JSON response
+// into out.
+func (p *Proxy) controlJSON(method, endpoint string, body io.Reader, out interface{}) error {
+	resp, err := p.doControlRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkControlResponse(endpoint, resp); err != nil {
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// controlRequest issues a control-port request and discards its body,
+// returning an error unless the proxy reports success.
+func (p *Proxy) controlRequest(method, endpoint string, body io.Reader) error {
+	resp, err := p.doControlRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkControlResponse(endpoint, resp)
+}
+
+func (p *Proxy) doControlRequest(method, endpoint string, body io.Reader) (*http.Response, error) {
+	if p.ControlPort == nil {
+		return nil, fmt.Errorf("no control port configured")
+	}
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", *p.ControlPort, endpoint)
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", endpoint, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", endpoint, err)
+	}
+	return resp, nil
+}
+
+func checkControlResponse(endpoint string, resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrControlEndpointNotSupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s request failed with status: %d", endpoint, resp.StatusCode)
+	}
+	return nil
+}
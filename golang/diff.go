@@ -0,0 +1,259 @@
+package httpplaybackproxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ContentHash returns a hex-encoded SHA-256 of r's body, read from whichever
+// of ContentUtf8, ContentBase64, or ContentFilePath is set (empty if none).
+// For ContentFilePath it streams the file straight into the hasher instead
+// of reading it into a byte slice first, so Diff and Merge can compare
+// inventories with large bodies without holding every one in memory at
+// once. inventoryDir is used to resolve ContentFilePath, the same as
+// GetResourceContentPath.
+func (r *Resource) ContentHash(inventoryDir string) (string, error) {
+	h := sha256.New()
+	switch {
+	case r.ContentUtf8 != nil:
+		h.Write([]byte(*r.ContentUtf8))
+	case r.ContentBase64 != nil:
+		data, err := base64.StdEncoding.DecodeString(*r.ContentBase64)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode inline content for %s %s: %w", r.Method, r.URL, err)
+		}
+		h.Write(data)
+	case r.ContentFilePath != nil:
+		f, err := os.Open(GetResourceContentPath(inventoryDir, r))
+		if err != nil {
+			return "", fmt.Errorf("failed to open content file for %s %s: %w", r.Method, r.URL, err)
+		}
+		defer f.Close()
+		if _, err := io.Copy(h, f); err != nil {
+			return "", fmt.Errorf("failed to read content file for %s %s: %w", r.Method, r.URL, err)
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// ResourceChange is one entry of InventoryDiff.Changed: the same (Method,
+// URL) resource as recorded in each inventory, differing in status code or
+// body.
+type ResourceChange struct {
+	Before Resource
+	After  Resource
+}
+
+// InventoryDiff is the result of Inventory.Diff: resources present in one
+// inventory but not the other, and resources present in both whose status
+// code or body differ.
+type InventoryDiff struct {
+	Added   []Resource
+	Removed []Resource
+	Changed []ResourceChange
+}
+
+// Diff compares a (recorded at aDir) against b (recorded at bDir), keyed by
+// (Method, URL), and reports what changed between them. Aimed at auditing
+// regressions between two recording sessions of the same scenario.
+func (a *Inventory) Diff(aDir string, b *Inventory, bDir string) (InventoryDiff, error) {
+	var diff InventoryDiff
+
+	aByKey := make(map[string]Resource, len(a.Resources))
+	for _, r := range a.Resources {
+		aByKey[resourceKey(r)] = r
+	}
+
+	seen := make(map[string]bool, len(a.Resources))
+	for _, rb := range b.Resources {
+		key := resourceKey(rb)
+		ra, inA := aByKey[key]
+		if !inA {
+			diff.Added = append(diff.Added, rb)
+			continue
+		}
+		seen[key] = true
+
+		changed, err := resourcesDiffer(ra, aDir, rb, bDir)
+		if err != nil {
+			return InventoryDiff{}, err
+		}
+		if changed {
+			diff.Changed = append(diff.Changed, ResourceChange{Before: ra, After: rb})
+		}
+	}
+
+	for _, ra := range a.Resources {
+		if !seen[resourceKey(ra)] {
+			diff.Removed = append(diff.Removed, ra)
+		}
+	}
+
+	return diff, nil
+}
+
+// MergeStrategy controls how Inventory.Merge resolves a (Method, URL)
+// present in both inventories being merged.
+type MergeStrategy string
+
+const (
+	// MergePreferA keeps a's resource on conflict.
+	MergePreferA MergeStrategy = "prefer_a"
+	// MergePreferB keeps b's resource on conflict.
+	MergePreferB MergeStrategy = "prefer_b"
+	// MergePreferNewer keeps whichever resource has the more recent
+	// response Date header, falling back to a's resource if either side is
+	// missing or fails to parse one.
+	MergePreferNewer MergeStrategy = "prefer_newer"
+	// MergeUnion keeps both resources rather than picking one, so the
+	// result carries two entries for that (Method, URL).
+	MergeUnion MergeStrategy = "union"
+)
+
+// Merge combines a (recorded at aDir) and b (recorded at bDir) into a new
+// Inventory, resolving resources that exist in both per strategy. Resources
+// unique to either side are always kept. EntryURL and DeviceType are taken
+// from a, falling back to b's if a has none. Every resource's content is
+// read from its source directory and re-staged through AddResource, so
+// SaveInventory re-homes it as a content-addressed file under the result's
+// own directory.
+func (a *Inventory) Merge(aDir string, b *Inventory, bDir string, strategy MergeStrategy) (*Inventory, error) {
+	result := &Inventory{EntryURL: a.EntryURL, DeviceType: a.DeviceType}
+	if result.EntryURL == nil {
+		result.EntryURL = b.EntryURL
+	}
+	if result.DeviceType == nil {
+		result.DeviceType = b.DeviceType
+	}
+
+	bByKey := make(map[string]Resource, len(b.Resources))
+	for _, r := range b.Resources {
+		bByKey[resourceKey(r)] = r
+	}
+
+	seen := make(map[string]bool, len(a.Resources))
+	for _, ra := range a.Resources {
+		key := resourceKey(ra)
+		rb, inB := bByKey[key]
+		if !inB {
+			if err := stageResource(result, ra, aDir); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		seen[key] = true
+
+		switch strategy {
+		case MergePreferB:
+			if err := stageResource(result, rb, bDir); err != nil {
+				return nil, err
+			}
+		case MergePreferNewer:
+			winner, winnerDir := ra, aDir
+			if bIsNewer, ok := isNewer(rb, ra); ok && bIsNewer {
+				winner, winnerDir = rb, bDir
+			}
+			if err := stageResource(result, winner, winnerDir); err != nil {
+				return nil, err
+			}
+		case MergeUnion:
+			if err := stageResource(result, ra, aDir); err != nil {
+				return nil, err
+			}
+			if err := stageResource(result, rb, bDir); err != nil {
+				return nil, err
+			}
+		default: // MergePreferA
+			if err := stageResource(result, ra, aDir); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, rb := range b.Resources {
+		if !seen[resourceKey(rb)] {
+			if err := stageResource(result, rb, bDir); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// stageResource reads r's body from dir and re-adds it to dst through
+// AddResource, the same re-homing step MergeInventories uses.
+func stageResource(dst *Inventory, r Resource, dir string) error {
+	body, err := harResourceBody(dir, &r)
+	if err != nil {
+		return fmt.Errorf("failed to read content for %s %s: %w", r.Method, r.URL, err)
+	}
+	r.ContentFilePath = nil
+	r.ContentUtf8 = nil
+	r.ContentBase64 = nil
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	return dst.AddResource(r, reader)
+}
+
+// resourceKey is the (Method, URL) identity Diff and Merge key resources by.
+func resourceKey(r Resource) string {
+	return r.Method + " " + r.URL
+}
+
+// resourcesDiffer reports whether ra (at aDir) and rb (at bDir) - the same
+// (Method, URL) resource recorded in two inventories - differ in status
+// code or body.
+func resourcesDiffer(ra Resource, aDir string, rb Resource, bDir string) (bool, error) {
+	if (ra.StatusCode == nil) != (rb.StatusCode == nil) {
+		return true, nil
+	}
+	if ra.StatusCode != nil && *ra.StatusCode != *rb.StatusCode {
+		return true, nil
+	}
+
+	hashA, err := ra.ContentHash(aDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash content for %s %s: %w", ra.Method, ra.URL, err)
+	}
+	hashB, err := rb.ContentHash(bDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash content for %s %s: %w", rb.Method, rb.URL, err)
+	}
+	return hashA != hashB, nil
+}
+
+// isNewer reports whether rb's response Date header is later than ra's. The
+// second return value is false if either side is missing a parseable Date
+// header, in which case the caller should fall back to preferring ra.
+func isNewer(rb, ra Resource) (bool, bool) {
+	dateB, okB := resourceDate(rb)
+	dateA, okA := resourceDate(ra)
+	if !okA || !okB {
+		return false, false
+	}
+	return dateB.After(dateA), true
+}
+
+// resourceDate parses r's recorded response Date header, per RFC 7231 (and
+// the older formats net/http accepts for compatibility).
+func resourceDate(r Resource) (time.Time, bool) {
+	raw, ok := r.RawHeaders["Date"]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := http.ParseTime(raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
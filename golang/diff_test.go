@@ -0,0 +1,133 @@
+package httpplaybackproxy
+
+import (
+	"testing"
+)
+
+func statusPtr(code uint16) *uint16 { return &code }
+
+func TestContentHash(t *testing.T) {
+	dir := t.TempDir()
+
+	r1 := Resource{Method: "GET", URL: "https://example.com/", ContentUtf8: strPtr("hello")}
+	r2 := Resource{Method: "GET", URL: "https://example.com/", ContentUtf8: strPtr("hello")}
+	r3 := Resource{Method: "GET", URL: "https://example.com/", ContentUtf8: strPtr("world")}
+
+	h1, err := r1.ContentHash(dir)
+	if err != nil {
+		t.Fatalf("ContentHash failed: %v", err)
+	}
+	h2, err := r2.ContentHash(dir)
+	if err != nil {
+		t.Fatalf("ContentHash failed: %v", err)
+	}
+	h3, err := r3.ContentHash(dir)
+	if err != nil {
+		t.Fatalf("ContentHash failed: %v", err)
+	}
+
+	if h1 != h2 {
+		t.Errorf("identical bodies hashed differently: %q vs %q", h1, h2)
+	}
+	if h1 == h3 {
+		t.Errorf("different bodies hashed the same: %q", h1)
+	}
+
+	empty := Resource{Method: "GET", URL: "https://example.com/missing"}
+	hEmpty, err := empty.ContentHash(dir)
+	if err != nil {
+		t.Fatalf("ContentHash on resource with no body failed: %v", err)
+	}
+	if hEmpty == "" {
+		t.Error("ContentHash on resource with no body should still return the empty-input hash, not an empty string")
+	}
+}
+
+func TestInventoryDiff(t *testing.T) {
+	aDir, bDir := t.TempDir(), t.TempDir()
+
+	a := &Inventory{Resources: []Resource{
+		{Method: "GET", URL: "https://example.com/unchanged", StatusCode: statusPtr(200), ContentUtf8: strPtr("same")},
+		{Method: "GET", URL: "https://example.com/changed", StatusCode: statusPtr(200), ContentUtf8: strPtr("before")},
+		{Method: "GET", URL: "https://example.com/removed", StatusCode: statusPtr(200)},
+	}}
+	b := &Inventory{Resources: []Resource{
+		{Method: "GET", URL: "https://example.com/unchanged", StatusCode: statusPtr(200), ContentUtf8: strPtr("same")},
+		{Method: "GET", URL: "https://example.com/changed", StatusCode: statusPtr(200), ContentUtf8: strPtr("after")},
+		{Method: "GET", URL: "https://example.com/added", StatusCode: statusPtr(201)},
+	}}
+
+	diff, err := a.Diff(aDir, b, bDir)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	if len(diff.Added) != 1 || diff.Added[0].URL != "https://example.com/added" {
+		t.Errorf("Added = %+v, want [added]", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].URL != "https://example.com/removed" {
+		t.Errorf("Removed = %+v, want [removed]", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].After.URL != "https://example.com/changed" {
+		t.Errorf("Changed = %+v, want [changed]", diff.Changed)
+	}
+}
+
+func TestInventoryMergeStrategies(t *testing.T) {
+	aDir, bDir := t.TempDir(), t.TempDir()
+
+	a := &Inventory{Resources: []Resource{
+		{Method: "GET", URL: "https://example.com/shared", ContentUtf8: strPtr("from-a")},
+		{Method: "GET", URL: "https://example.com/a-only", ContentUtf8: strPtr("a-only")},
+	}}
+	b := &Inventory{Resources: []Resource{
+		{Method: "GET", URL: "https://example.com/shared", ContentUtf8: strPtr("from-b")},
+		{Method: "GET", URL: "https://example.com/b-only", ContentUtf8: strPtr("b-only")},
+	}}
+
+	t.Run("prefer_a keeps a's version of a conflict", func(t *testing.T) {
+		merged, err := a.Merge(aDir, b, bDir, MergePreferA)
+		if err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+		if len(merged.Resources) != 3 {
+			t.Fatalf("expected 3 resources, got %d", len(merged.Resources))
+		}
+		body, _ := harResourceBody("", resourceByURL(merged, "https://example.com/shared"))
+		if string(body) != "from-a" {
+			t.Errorf("shared body = %q, want %q", body, "from-a")
+		}
+	})
+
+	t.Run("prefer_b keeps b's version of a conflict", func(t *testing.T) {
+		merged, err := a.Merge(aDir, b, bDir, MergePreferB)
+		if err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+		body, _ := harResourceBody("", resourceByURL(merged, "https://example.com/shared"))
+		if string(body) != "from-b" {
+			t.Errorf("shared body = %q, want %q", body, "from-b")
+		}
+	})
+
+	t.Run("union keeps both versions of a conflict", func(t *testing.T) {
+		merged, err := a.Merge(aDir, b, bDir, MergeUnion)
+		if err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+		if len(merged.Resources) != 4 {
+			t.Fatalf("expected 4 resources (union keeps both sides of the conflict), got %d", len(merged.Resources))
+		}
+	})
+}
+
+// resourceByURL returns the first resource in inv with the given URL,
+// panicking if none matches - a small test helper, not part of the public API.
+func resourceByURL(inv *Inventory, url string) *Resource {
+	for i := range inv.Resources {
+		if inv.Resources[i].URL == url {
+			return &inv.Resources[i]
+		}
+	}
+	panic("resource not found: " + url)
+}
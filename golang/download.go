@@ -4,12 +4,17 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -20,6 +25,9 @@ const (
 
 	// Default download timeout
 	defaultDownloadTimeout = 5 * time.Minute
+
+	// Default timeout spent waiting on another process's version lock
+	defaultLockTimeout = 2 * time.Minute
 )
 
 // getPlatform returns the current platform string (e.g., "darwin-arm64")
@@ -50,10 +58,10 @@ func getBinaryName() string {
 	return "http-playback-proxy"
 }
 
-// getBinaryPath returns the expected path to the binary
-func getBinaryPath() string {
-	platform := getPlatform()
-	return filepath.Join("bin", platform, getBinaryName())
+// versionBinaryPath returns the cache-relative path to a specific version's
+// binary for the current platform: bin/<version>/<platform>/<binary-name>.
+func versionBinaryPath(version string) string {
+	return filepath.Join("bin", version, getPlatform(), getBinaryName())
 }
 
 // getCacheDir returns a writable cache directory for downloaded binaries
@@ -106,17 +114,15 @@ func getPackageRoot() (string, error) {
 	return packageDir, nil
 }
 
-// checkBinaryExists checks if the binary exists in the package or cache
-// Note: Go modules are installed read-only in $GOPATH/pkg/mod, so binaries
-// cannot be bundled with the module. This function checks the package directory
-// only for development purposes (when working in the source tree).
-// In production, binaries are always downloaded to the cache directory.
-func checkBinaryExists() bool {
+// checkVersionExists checks if a specific version's binary exists in the
+// package or cache directory.
+func checkVersionExists(version string) bool {
+	relPath := versionBinaryPath(version)
+
 	// Check package directory first (development only)
 	packageRoot, err := getPackageRoot()
 	if err == nil {
-		binPath := filepath.Join(packageRoot, getBinaryPath())
-		if _, err := os.Stat(binPath); err == nil {
+		if _, err := os.Stat(filepath.Join(packageRoot, relPath)); err == nil {
 			return true
 		}
 	}
@@ -124,8 +130,7 @@ func checkBinaryExists() bool {
 	// Check cache directory (production)
 	cacheDir, err := getCacheDir()
 	if err == nil {
-		binPath := filepath.Join(cacheDir, getBinaryPath())
-		if _, err := os.Stat(binPath); err == nil {
+		if _, err := os.Stat(filepath.Join(cacheDir, relPath)); err == nil {
 			return true
 		}
 	}
@@ -133,45 +138,172 @@ func checkBinaryExists() bool {
 	return false
 }
 
+// checkBinaryExists checks if the current Version's binary exists in the
+// package or cache.
+func checkBinaryExists() bool {
+	return checkVersionExists(Version)
+}
+
 // CheckBinaryExists is a public wrapper for checking if the binary exists
 func CheckBinaryExists() bool {
 	return checkBinaryExists()
 }
 
-// downloadBinary downloads the pre-built binary from GitHub Releases
+// versionLock is an advisory, cross-process exclusive lock held while a
+// version is being downloaded and installed, so that concurrent `go test
+// ./...` invocations (or any other concurrent consumers of this package)
+// don't race to extract the same archive into the same cache directory.
+type versionLock struct {
+	path string
+}
+
+// acquireVersionLock creates <cacheDir>/bin/<version>.lock exclusively,
+// retrying with backoff until it succeeds or timeout elapses. The lock is
+// released (and the lockfile removed) by calling release().
+func acquireVersionLock(cacheDir, version string) (*versionLock, error) {
+	lockDir := filepath.Join(cacheDir, "bin")
+	if err := os.MkdirAll(lockDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	lockPath := filepath.Join(lockDir, version+".lock")
+
+	deadline := time.Now().Add(defaultLockTimeout)
+	backoff := 50 * time.Millisecond
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return &versionLock{path: lockPath}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s", lockPath)
+		}
+		time.Sleep(backoff)
+		if backoff < time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// release removes the lockfile, allowing other processes to proceed.
+func (l *versionLock) release() {
+	os.Remove(l.path)
+}
+
+// downloadBinary downloads the pre-built binary for the current Version
 func downloadBinary() error {
 	return downloadBinaryVersion(Version)
 }
 
-// downloadBinaryVersion downloads a specific version of the pre-built binary
+// downloadBinaryVersion downloads, verifies, and atomically installs a
+// specific version of the pre-built binary into the cache directory.
 func downloadBinaryVersion(version string) error {
-	// Try to download to cache directory first
+	_, err := EnsureBinaryVersion(version)
+	return err
+}
+
+// EnsureBinaryVersion ensures a specific version of the binary is present in
+// the cache (downloading, verifying against its SHA-256 sidecar, and
+// installing it if necessary) and returns the path to the installed binary.
+func EnsureBinaryVersion(version string) (string, error) {
 	cacheDir, err := getCacheDir()
 	if err != nil {
-		return fmt.Errorf("failed to get cache directory: %w", err)
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
 	}
 
-	// Try cache directory first
-	targetDir := cacheDir
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		// If cache directory creation fails, try package directory
-		packageRoot, projErr := getPackageRoot()
-		if projErr != nil {
-			return fmt.Errorf("failed to get target directory: cache=%w, package=%w", err, projErr)
-		}
-		targetDir = packageRoot
-		fmt.Fprintf(os.Stderr, "Warning: Could not create cache directory, using package directory: %v\n", err)
+	finalPath := filepath.Join(cacheDir, versionBinaryPath(version))
+	if checkVersionExists(version) {
+		touchLastUsed(cacheDir, version)
+		return GetBinaryPathForVersion(version)
+	}
+
+	lock, err := acquireVersionLock(cacheDir, version)
+	if err != nil {
+		return "", fmt.Errorf("failed to acquire version lock: %w", err)
+	}
+	defer lock.release()
+
+	// Another process may have installed this version while we waited.
+	if checkVersionExists(version) {
+		touchLastUsed(cacheDir, version)
+		return GetBinaryPathForVersion(version)
 	}
 
 	platform := getPlatform()
 	archiveName := fmt.Sprintf("http-playback-proxy-v%s-%s.tar.gz", version, platform)
-	url := fmt.Sprintf("%s/v%s/%s", baseURL, version, archiveName)
+	archiveURL := fmt.Sprintf("%s/v%s/%s", baseURL, version, archiveName)
 
 	fmt.Printf("Downloading http-playback-proxy binary for %s...\n", platform)
-	fmt.Printf("URL: %s\n", url)
-	fmt.Printf("Target: %s\n", targetDir)
+	fmt.Printf("URL: %s\n", archiveURL)
+
+	tmpDir, err := os.MkdirTemp(cacheDir, ".tmp-"+version+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Download the archive to disk (rather than streaming straight into the
+	// extractor) so it can be verified against its SHA-256 sidecar - which,
+	// by the usual <archive>.sha256 convention, covers the archive bytes,
+	// not the binary inside it - before anything from it is extracted.
+	archivePath := filepath.Join(tmpDir, archiveName)
+	if err := downloadFile(archiveURL, archivePath); err != nil {
+		return "", err
+	}
+
+	expectedSum, err := downloadChecksum(fmt.Sprintf("%s.sha256", archiveURL))
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksum: %w", err)
+	}
+
+	actualSum, err := sha256File(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash downloaded archive: %w", err)
+	}
+	if expectedSum != actualSum {
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", archiveName, expectedSum, actualSum)
+	}
 
-	// Create HTTP client with timeout and context
+	extractDir := filepath.Join(tmpDir, "extracted")
+	if err := extractArchive(archivePath, extractDir); err != nil {
+		return "", err
+	}
+
+	binaryName := getBinaryName()
+	extractedBinary := filepath.Join(extractDir, binaryName)
+	if _, err := os.Stat(extractedBinary); err != nil {
+		return "", fmt.Errorf("archive did not contain expected binary %s: %w", binaryName, err)
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(extractedBinary, 0755); err != nil {
+			return "", fmt.Errorf("failed to make binary executable: %w", err)
+		}
+	}
+
+	finalDir := filepath.Dir(finalPath)
+	if err := os.MkdirAll(filepath.Dir(finalDir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create version directory: %w", err)
+	}
+	// finalDir (bin/<version>/<platform>) must not exist yet for the rename
+	// to be atomic; remove any partial leftovers from a prior failed attempt.
+	os.RemoveAll(finalDir)
+	if err := os.Rename(extractDir, finalDir); err != nil {
+		return "", fmt.Errorf("failed to install binary: %w", err)
+	}
+
+	fmt.Printf("Successfully installed http-playback-proxy v%s to %s\n", version, finalDir)
+	touchLastUsed(cacheDir, version)
+	return finalPath, nil
+}
+
+// downloadFile downloads the resource at url to destPath, truncating
+// destPath if it already exists.
+func downloadFile(url, destPath string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultDownloadTimeout)
 	defer cancel()
 
@@ -180,11 +312,7 @@ func downloadBinaryVersion(version string) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	client := &http.Client{
-		Timeout: defaultDownloadTimeout,
-	}
-
-	// Download the tar.gz archive
+	client := &http.Client{Timeout: defaultDownloadTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to download binary: %w", err)
@@ -195,15 +323,38 @@ func downloadBinaryVersion(version string) error {
 		return fmt.Errorf("failed to download binary: HTTP %d", resp.StatusCode)
 	}
 
-	// Extract the tar.gz archive
-	gzr, err := gzip.NewReader(resp.Body)
+	f, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+	return nil
+}
+
+// extractArchive extracts the tar.gz archive at archivePath into destDir,
+// which is created if it doesn't already exist.
+func extractArchive(archivePath, destDir string) error {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create extract directory: %w", err)
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	gzr, err := gzip.NewReader(f)
 	if err != nil {
 		return fmt.Errorf("failed to create gzip reader: %w", err)
 	}
 	defer gzr.Close()
 
 	tr := tar.NewReader(gzr)
-
 	for {
 		header, err := tr.Next()
 		if err == io.EOF {
@@ -213,7 +364,10 @@ func downloadBinaryVersion(version string) error {
 			return fmt.Errorf("failed to read tar: %w", err)
 		}
 
-		target := filepath.Join(targetDir, "bin", platform, header.Name)
+		target := filepath.Join(destDir, header.Name)
+		if !isWithinDir(destDir, target) {
+			return fmt.Errorf("archive entry %q escapes extract directory", header.Name)
+		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
@@ -221,12 +375,11 @@ func downloadBinaryVersion(version string) error {
 				return fmt.Errorf("failed to create directory: %w", err)
 			}
 		case tar.TypeReg:
-			dir := filepath.Dir(target)
-			if err := os.MkdirAll(dir, 0755); err != nil {
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
 				return fmt.Errorf("failed to create directory: %w", err)
 			}
 
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
 			if err != nil {
 				return fmt.Errorf("failed to create file: %w", err)
 			}
@@ -236,20 +389,139 @@ func downloadBinaryVersion(version string) error {
 				return fmt.Errorf("failed to write file: %w", err)
 			}
 			f.Close()
-
-			// Make binary executable on Unix-like systems
-			if runtime.GOOS != "windows" {
-				if err := os.Chmod(target, 0755); err != nil {
-					return fmt.Errorf("failed to make binary executable: %w", err)
-				}
-			}
 		}
 	}
 
-	fmt.Printf("Successfully downloaded and extracted binary to %s\n", targetDir)
 	return nil
 }
 
+// isWithinDir reports whether target (as produced by filepath.Join(dir,
+// name) for an untrusted tar entry name) is dir itself or a descendant of
+// it, guarding extractArchive against a "zip-slip" entry (e.g. "../../etc/
+// passwd") that would otherwise write outside destDir.
+func isWithinDir(dir, target string) bool {
+	rel, err := filepath.Rel(dir, target)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (!strings.HasPrefix(rel, ".."+string(filepath.Separator)) && rel != "..")
+}
+
+// downloadChecksum fetches a `.sha256` sidecar and returns the hex digest it
+// contains. Sidecars may be in the coreutils `sha256sum` format (hash,
+// whitespace, filename) or a bare hex digest.
+func downloadChecksum(url string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultDownloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := &http.Client{Timeout: defaultDownloadTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksum: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download checksum: HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read checksum: %w", err)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum file")
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// sha256File returns the lowercase hex SHA-256 digest of the file at path.
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// touchLastUsed records the time a version was last used, so
+// PruneBinaryCache can evict the least-recently-used versions first.
+func touchLastUsed(cacheDir, version string) {
+	marker := filepath.Join(cacheDir, "bin", version, ".last-used")
+	now := time.Now()
+	if err := os.Chtimes(marker, now, now); err != nil {
+		os.WriteFile(marker, nil, 0644)
+	}
+}
+
+// PruneBinaryCache removes all but the `keep` most recently used installed
+// versions from the binary cache, freeing disk space on long-lived machines
+// that have accumulated many versions over time. keep <= 0 removes every
+// cached version.
+func PruneBinaryCache(keep int) error {
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return fmt.Errorf("failed to get cache directory: %w", err)
+	}
+
+	binDir := filepath.Join(cacheDir, "bin")
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	type versionEntry struct {
+		name     string
+		lastUsed time.Time
+	}
+	var versions []versionEntry
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue // skip lockfiles and other stray files
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		lastUsed := info.ModTime()
+		if markerInfo, err := os.Stat(filepath.Join(binDir, entry.Name(), ".last-used")); err == nil {
+			lastUsed = markerInfo.ModTime()
+		}
+		versions = append(versions, versionEntry{name: entry.Name(), lastUsed: lastUsed})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].lastUsed.After(versions[j].lastUsed)
+	})
+
+	if keep < 0 {
+		keep = 0
+	}
+	var firstErr error
+	for i := keep; i < len(versions); i++ {
+		if err := os.RemoveAll(filepath.Join(binDir, versions[i].name)); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to remove cached version %s: %w", versions[i].name, err)
+		}
+	}
+	return firstErr
+}
+
 // DownloadBinary is a public wrapper for downloading the binary with the default version
 func DownloadBinary(version string) error {
 	if version == "" {
@@ -258,31 +530,31 @@ func DownloadBinary(version string) error {
 	return downloadBinaryVersion(version)
 }
 
-// EnsureBinary ensures the binary is available, downloading if necessary.
-// This is the recommended way to initialize the library.
-// Returns an error if the binary cannot be found or downloaded.
+// EnsureBinary ensures the current Version's binary is available,
+// downloading if necessary. This is the recommended way to initialize the
+// library. Returns an error if the binary cannot be found or downloaded.
 func EnsureBinary() error {
-	if checkBinaryExists() {
-		return nil
-	}
-
-	fmt.Println("Pre-built binary not found. Attempting to download from GitHub Releases...")
-
-	if err := downloadBinary(); err != nil {
-		return fmt.Errorf("failed to download binary v%s: %w", Version, err)
-	}
-
-	return nil
+	_, err := EnsureBinaryVersion(Version)
+	return err
 }
 
-// GetBinaryPath returns the full path to the binary
-// Returns an error if the binary doesn't exist
+// GetBinaryPath returns the full path to the current Version's binary.
+// Returns an error if the binary doesn't exist.
 // Priority: 1) Package directory (development), 2) Cache directory (production)
 func GetBinaryPath() (string, error) {
+	return GetBinaryPathForVersion(Version)
+}
+
+// GetBinaryPathForVersion returns the full path to a specific version's
+// binary. Returns an error if that version's binary doesn't exist.
+// Priority: 1) Package directory (development), 2) Cache directory (production)
+func GetBinaryPathForVersion(version string) (string, error) {
+	relPath := versionBinaryPath(version)
+
 	// Check package directory first (development only)
 	packageRoot, err := getPackageRoot()
 	if err == nil {
-		binPath := filepath.Join(packageRoot, getBinaryPath())
+		binPath := filepath.Join(packageRoot, relPath)
 		if _, err := os.Stat(binPath); err == nil {
 			return binPath, nil
 		}
@@ -291,11 +563,11 @@ func GetBinaryPath() (string, error) {
 	// Check cache directory (production)
 	cacheDir, err := getCacheDir()
 	if err == nil {
-		binPath := filepath.Join(cacheDir, getBinaryPath())
+		binPath := filepath.Join(cacheDir, relPath)
 		if _, err := os.Stat(binPath); err == nil {
 			return binPath, nil
 		}
 	}
 
-	return "", fmt.Errorf("binary not found, please call EnsureBinary() first")
+	return "", fmt.Errorf("binary not found for version %s, please call EnsureBinaryVersion() first", version)
 }
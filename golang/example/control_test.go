@@ -0,0 +1,70 @@
+package example
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	proxy "github.com/pagespeed-quest/http-playback-proxy/golang"
+)
+
+// freePort asks the OS for an unused TCP port, the same way t.TempDir
+// gives this test an unused directory.
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// TestControlAPI exercises the control-port client methods. The endpoints
+// they hit (/_status, /_stats, /_reload, /_pause, /_resume, /_snapshot) are
+// newer than /_shutdown, so this skips gracefully against any binary that
+// hasn't grown them yet instead of failing the suite.
+func TestControlAPI(t *testing.T) {
+	tmpDir := t.TempDir()
+	controlPort := freePort(t)
+
+	p, err := proxy.StartRecording(proxy.RecordingOptions{
+		Port:         0,
+		InventoryDir: tmpDir,
+		ControlPort:  &controlPort,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start recording proxy: %v", err)
+	}
+	defer func() {
+		if p.IsRunning() {
+			p.Stop()
+		}
+	}()
+
+	time.Sleep(2 * time.Second)
+
+	status, err := p.Status()
+	if errors.Is(err, proxy.ErrControlEndpointNotSupported) {
+		t.Skip("this proxy binary doesn't implement /_status yet")
+	}
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.Mode != proxy.ModeRecording {
+		t.Errorf("expected mode %q, got %q", proxy.ModeRecording, status.Mode)
+	}
+
+	if _, err := p.Stats(); err != nil && !errors.Is(err, proxy.ErrControlEndpointNotSupported) {
+		t.Errorf("Stats failed: %v", err)
+	}
+
+	if err := p.PauseRecording(); err != nil && !errors.Is(err, proxy.ErrControlEndpointNotSupported) {
+		t.Errorf("PauseRecording failed: %v", err)
+	}
+
+	if err := p.ResumeRecording(); err != nil && !errors.Is(err, proxy.ErrControlEndpointNotSupported) {
+		t.Errorf("ResumeRecording failed: %v", err)
+	}
+}
@@ -0,0 +1,343 @@
+package httpplaybackproxy
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// harLog is the top-level HAR 1.2 document (http://www.softwareishard.com/blog/har-12-spec/).
+// Only the fields this module reads or writes are modeled; any other field
+// in an imported archive is ignored and is not carried into an export.
+type harLog struct {
+	Log harLogBody `json:"log"`
+}
+
+type harLogBody struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Pages   []harPage  `json:"pages,omitempty"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harPage struct {
+	StartedDateTime string `json:"startedDateTime"`
+	ID              string `json:"id"`
+	Title           string `json:"title"`
+}
+
+type harEntry struct {
+	Pageref         string      `json:"pageref,omitempty"`
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers []harHeader `json:"headers"`
+}
+
+type harResponse struct {
+	Status     int         `json:"status"`
+	StatusText string      `json:"statusText"`
+	Headers    []harHeader `json:"headers"`
+	Content    harContent  `json:"content"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// ImportHAR reads a HAR 1.2 archive (as produced by Chrome DevTools, Firefox,
+// Charles, Fiddler, etc.) and writes it out as an inventory in inventoryDir,
+// creating the directory and its contents/ subdirectory as needed.
+func ImportHAR(harPath, inventoryDir string) error {
+	data, err := os.ReadFile(harPath)
+	if err != nil {
+		return fmt.Errorf("failed to read HAR file: %w", err)
+	}
+
+	var har harLog
+	if err := json.Unmarshal(data, &har); err != nil {
+		return fmt.Errorf("failed to parse HAR file: %w", err)
+	}
+
+	contentsDir := filepath.Join(inventoryDir, "contents")
+	if err := os.MkdirAll(contentsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create contents directory: %w", err)
+	}
+
+	var deviceType DeviceType
+	inventory := &Inventory{}
+
+	for i, entry := range har.Log.Entries {
+		resource := Resource{
+			Method: entry.Request.Method,
+			URL:    entry.Request.URL,
+			TtfbMs: harTtfbMs(entry.Time, entry.Timings),
+		}
+
+		status := uint16(entry.Response.Status)
+		resource.StatusCode = &status
+
+		// The original response's Content-Encoding (if any) is dropped
+		// entirely, both from RawHeaders and Resource.ContentEncoding: the
+		// body written below is HAR's already-decoded content.text, so
+		// Resource.ContentEncoding - which describes the actual encoding of
+		// the bytes on disk, per the recorder's convention - must stay nil
+		// (identity) here, and replaying the original header over a
+		// plaintext body would be equally wrong.
+		rawHeaders := make(map[string]string, len(entry.Response.Headers))
+		for _, h := range entry.Response.Headers {
+			if strings.EqualFold(h.Name, "Content-Encoding") {
+				continue
+			}
+			rawHeaders[h.Name] = h.Value
+		}
+		if len(rawHeaders) > 0 {
+			resource.RawHeaders = rawHeaders
+		}
+
+		if entry.Response.Content.MimeType != "" {
+			mime, charset := splitContentType(entry.Response.Content.MimeType)
+			if mime != "" {
+				resource.ContentTypeMime = &mime
+			}
+			if charset != "" {
+				resource.ContentTypeCharset = &charset
+			}
+		}
+
+		if entry.Response.Content.Text != "" {
+			body, err := harDecodeContent(entry.Response.Content)
+			if err != nil {
+				return fmt.Errorf("failed to decode content for entry %d: %w", i, err)
+			}
+
+			fileName := fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%d:%s:%s", i, resource.Method, resource.URL))))[:16]
+			contentPath := filepath.Join("contents", fileName)
+			if err := os.WriteFile(filepath.Join(inventoryDir, contentPath), body, 0644); err != nil {
+				return fmt.Errorf("failed to write content file for entry %d: %w", i, err)
+			}
+			resource.ContentFilePath = &contentPath
+		}
+
+		if deviceType == "" {
+			if isMobileUserAgent(harHeaderValue(entry.Request.Headers, "User-Agent")) {
+				deviceType = DeviceTypeMobile
+			}
+		}
+
+		inventory.Resources = append(inventory.Resources, resource)
+	}
+
+	if deviceType != "" {
+		inventory.DeviceType = &deviceType
+	}
+	if len(har.Log.Entries) > 0 {
+		entryURL := har.Log.Entries[0].Request.URL
+		inventory.EntryURL = &entryURL
+	}
+
+	return SaveInventory(GetInventoryPath(inventoryDir), inventory)
+}
+
+// ExportHAR reads the inventory in inventoryDir and writes it out as a HAR
+// 1.2 archive at harPath, with a creator block identifying this module and
+// one pages entry per unique origin so DevTools-style waterfalls render
+// correctly.
+func ExportHAR(inventoryDir, harPath string) error {
+	inventory, err := LoadInventory(GetInventoryPath(inventoryDir))
+	if err != nil {
+		return fmt.Errorf("failed to load inventory: %w", err)
+	}
+
+	har := harLog{
+		Log: harLogBody{
+			Version: "1.2",
+			Creator: harCreator{
+				Name:    "http-playback-proxy",
+				Version: Version,
+			},
+		},
+	}
+
+	pageIDs := map[string]string{}
+	now := time.Unix(0, 0).UTC().Format(time.RFC3339)
+
+	for _, resource := range inventory.Resources {
+		origin := resourceOrigin(resource.URL)
+		pageID, ok := pageIDs[origin]
+		if !ok {
+			pageID = fmt.Sprintf("page_%d", len(pageIDs)+1)
+			pageIDs[origin] = pageID
+			har.Log.Pages = append(har.Log.Pages, harPage{
+				StartedDateTime: now,
+				ID:              pageID,
+				Title:           origin,
+			})
+		}
+
+		entry := harEntry{
+			Pageref:         pageID,
+			StartedDateTime: now,
+			Request: harRequest{
+				Method: resource.Method,
+				URL:    resource.URL,
+			},
+			Timings: harTimings{
+				Wait: float64(resource.TtfbMs),
+			},
+		}
+		entry.Time = entry.Timings.Wait
+
+		if resource.StatusCode != nil {
+			entry.Response.Status = int(*resource.StatusCode)
+		}
+
+		for name, value := range resource.RawHeaders {
+			entry.Response.Headers = append(entry.Response.Headers, harHeader{Name: name, Value: value})
+		}
+		if resource.ContentEncoding != nil {
+			entry.Response.Headers = append(entry.Response.Headers, harHeader{Name: "Content-Encoding", Value: string(*resource.ContentEncoding)})
+		}
+
+		mimeType := ""
+		if resource.ContentTypeMime != nil {
+			mimeType = *resource.ContentTypeMime
+			if resource.ContentTypeCharset != nil {
+				mimeType += "; charset=" + *resource.ContentTypeCharset
+			}
+		}
+		entry.Response.Content.MimeType = mimeType
+
+		body, err := harResourceBody(inventoryDir, &resource)
+		if err != nil {
+			return fmt.Errorf("failed to read content for %s %s: %w", resource.Method, resource.URL, err)
+		}
+		if body != nil {
+			entry.Response.Content.Size = len(body)
+			entry.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+			entry.Response.Content.Encoding = "base64"
+		}
+
+		har.Log.Entries = append(har.Log.Entries, entry)
+	}
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal HAR: %w", err)
+	}
+
+	return os.WriteFile(harPath, data, 0644)
+}
+
+// harTtfbMs derives the TTFB in milliseconds from HAR timings, preferring
+// the explicit "wait" field and falling back to entryTimeMs (the entry's
+// total "time") minus every other phase. HAR uses -1 for a phase that
+// doesn't apply to this entry, so those are not subtracted.
+func harTtfbMs(entryTimeMs float64, t harTimings) uint64 {
+	if t.Wait > 0 {
+		return uint64(t.Wait)
+	}
+
+	derived := entryTimeMs
+	for _, phase := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Receive} {
+		if phase > 0 {
+			derived -= phase
+		}
+	}
+	if derived < 0 {
+		return 0
+	}
+	return uint64(derived)
+}
+
+func harDecodeContent(content harContent) ([]byte, error) {
+	if content.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(content.Text)
+	}
+	return []byte(content.Text), nil
+}
+
+func harResourceBody(inventoryDir string, resource *Resource) ([]byte, error) {
+	switch {
+	case resource.ContentUtf8 != nil:
+		return []byte(*resource.ContentUtf8), nil
+	case resource.ContentBase64 != nil:
+		return base64.StdEncoding.DecodeString(*resource.ContentBase64)
+	case resource.ContentFilePath != nil:
+		return os.ReadFile(GetResourceContentPath(inventoryDir, resource))
+	default:
+		return nil, nil
+	}
+}
+
+func resourceOrigin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// harHeaderValue returns the value of the first header in headers whose
+// name matches name case-insensitively, or "" if none does.
+func harHeaderValue(headers []harHeader, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+func isMobileUserAgent(userAgent string) bool {
+	for _, token := range []string{"Mobile", "Android", "iPhone"} {
+		if strings.Contains(userAgent, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitContentType(contentType string) (mime, charset string) {
+	parts := strings.SplitN(contentType, ";", 2)
+	mime = strings.TrimSpace(parts[0])
+	if len(parts) == 2 {
+		charset = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(parts[1]), "charset="))
+	}
+	return mime, charset
+}
@@ -0,0 +1,146 @@
+package httpplaybackproxy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHarTtfbMs(t *testing.T) {
+	tests := []struct {
+		name        string
+		entryTimeMs float64
+		timings     harTimings
+		want        uint64
+	}{
+		{
+			name:        "wait field present",
+			entryTimeMs: 500,
+			timings:     harTimings{Wait: 120, Blocked: -1, DNS: -1, Connect: -1, Send: 1, Receive: 50},
+			want:        120,
+		},
+		{
+			name:        "falls back to entry time minus other phases",
+			entryTimeMs: 100,
+			timings:     harTimings{Wait: 0, Blocked: 10, DNS: 20, Connect: -1, Send: 5, Receive: 15},
+			want:        50, // 100 - 10 - 20 - 5 - 15
+		},
+		{
+			name:        "ignores HAR's -1 not-applicable sentinel",
+			entryTimeMs: 80,
+			timings:     harTimings{Wait: 0, Blocked: -1, DNS: -1, Connect: -1, Send: -1, Receive: -1},
+			want:        80,
+		},
+		{
+			name:        "never goes negative",
+			entryTimeMs: 10,
+			timings:     harTimings{Wait: 0, Blocked: 50, DNS: -1, Connect: -1, Send: -1, Receive: -1},
+			want:        0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := harTtfbMs(tt.entryTimeMs, tt.timings)
+			if got != tt.want {
+				t.Errorf("harTtfbMs(%v, %+v) = %d, want %d", tt.entryTimeMs, tt.timings, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHarHeaderValue(t *testing.T) {
+	headers := []harHeader{
+		{Name: "Content-Type", Value: "text/html"},
+		{Name: "Content-Encoding", Value: "gzip"},
+	}
+
+	if got := harHeaderValue(headers, "content-encoding"); got != "gzip" {
+		t.Errorf("harHeaderValue case-insensitive lookup = %q, want %q", got, "gzip")
+	}
+	if got := harHeaderValue(headers, "X-Missing"); got != "" {
+		t.Errorf("harHeaderValue for missing header = %q, want empty", got)
+	}
+}
+
+// TestImportHARDropsContentEncoding verifies that a gzip-encoded HAR entry
+// round-trips through ImportHAR/ExportHAR without ever claiming the
+// (already-decoded) stored body is gzip-encoded.
+func TestImportHARDropsContentEncoding(t *testing.T) {
+	harDir := t.TempDir()
+	harPath := filepath.Join(harDir, "recording.har")
+	harJSON := `{
+		"log": {
+			"version": "1.2",
+			"creator": {"name": "test", "version": "1"},
+			"entries": [{
+				"startedDateTime": "2024-01-01T00:00:00Z",
+				"time": 100,
+				"request": {
+					"method": "GET",
+					"url": "https://example.com/",
+					"headers": [{"name": "User-Agent", "value": "Mozilla/5.0 (iPhone) Mobile"}]
+				},
+				"response": {
+					"status": 200,
+					"statusText": "OK",
+					"headers": [
+						{"name": "Content-Type", "value": "text/plain"},
+						{"name": "Content-Encoding", "value": "gzip"}
+					],
+					"content": {"size": 5, "mimeType": "text/plain", "text": "hello"}
+				},
+				"timings": {"blocked": -1, "dns": -1, "connect": -1, "send": 1, "wait": 20, "receive": 2}
+			}]
+		}
+	}`
+	if err := os.WriteFile(harPath, []byte(harJSON), 0644); err != nil {
+		t.Fatalf("failed to write test HAR: %v", err)
+	}
+
+	inventoryDir := t.TempDir()
+	if err := ImportHAR(harPath, inventoryDir); err != nil {
+		t.Fatalf("ImportHAR failed: %v", err)
+	}
+
+	inv, err := LoadInventory(GetInventoryPath(inventoryDir))
+	if err != nil {
+		t.Fatalf("LoadInventory failed: %v", err)
+	}
+	if len(inv.Resources) != 1 {
+		t.Fatalf("expected 1 resource, got %d", len(inv.Resources))
+	}
+	resource := inv.Resources[0]
+
+	if resource.ContentEncoding != nil {
+		t.Errorf("ContentEncoding = %v, want nil since the stored body is decoded text", *resource.ContentEncoding)
+	}
+	if _, ok := resource.RawHeaders["Content-Encoding"]; ok {
+		t.Error("RawHeaders should not carry the original Content-Encoding")
+	}
+	if inv.DeviceType == nil || *inv.DeviceType != DeviceTypeMobile {
+		t.Errorf("DeviceType = %v, want mobile (User-Agent carried \"Mobile\")", inv.DeviceType)
+	}
+
+	body, err := os.ReadFile(GetResourceContentPath(inventoryDir, &resource))
+	if err != nil {
+		t.Fatalf("failed to read stored content: %v", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("stored body = %q, want %q", body, "hello")
+	}
+
+	exportPath := filepath.Join(t.TempDir(), "roundtrip.har")
+	if err := ExportHAR(inventoryDir, exportPath); err != nil {
+		t.Fatalf("ExportHAR failed: %v", err)
+	}
+
+	exported, err := os.ReadFile(exportPath)
+	if err != nil {
+		t.Fatalf("failed to read exported HAR: %v", err)
+	}
+	if strings.Contains(string(exported), "Content-Encoding") {
+		t.Error("exported HAR should not re-emit Content-Encoding over the decoded body")
+	}
+}
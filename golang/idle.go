@@ -0,0 +1,71 @@
+package httpplaybackproxy
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// maxConsecutiveStatsFailures bounds how many times in a row startIdleMonitor
+// tolerates a failed /_stats poll before giving up on idle detection
+// entirely. A transient control-API hiccup self-heals on the next tick, but
+// a binary that doesn't support the endpoint at all (Stats returns
+// ErrControlEndpointNotSupported) never will, and polling it forever would
+// silently defeat the whole point of IdleTimeout.
+const maxConsecutiveStatsFailures = 4
+
+// startIdleMonitor polls the control API's /_stats endpoint every
+// idleTimeout/4 and calls p.Stop once idleTimeout has passed without the
+// request count changing. It exits on its own once p's lifetime context is
+// canceled (by Stop or otherwise), so callers don't need to manage it. If
+// /_stats fails maxConsecutiveStatsFailures times in a row, the monitor gives
+// up and reports it to stderr rather than polling a dead endpoint forever.
+// That stderr line is the only report: StartXxx's IdleTimeout validation
+// checks only that a control port or listener is configured, not that the
+// binary serves /_stats.
+func startIdleMonitor(p *Proxy, idleTimeout time.Duration) {
+	interval := idleTimeout / 4
+	if interval <= 0 {
+		interval = idleTimeout
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var lastCount uint64
+		lastActivity := time.Now()
+		haveBaseline := false
+		consecutiveFailures := 0
+
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				stats, err := p.Stats()
+				if err != nil {
+					consecutiveFailures++
+					if consecutiveFailures >= maxConsecutiveStatsFailures {
+						fmt.Fprintf(os.Stderr, "idle-timeout monitor: giving up after %d consecutive /_stats failures: %v\n", consecutiveFailures, err)
+						return
+					}
+					continue
+				}
+				consecutiveFailures = 0
+
+				if !haveBaseline || stats.RequestCount != lastCount {
+					lastCount = stats.RequestCount
+					lastActivity = time.Now()
+					haveBaseline = true
+					continue
+				}
+
+				if time.Since(lastActivity) >= idleTimeout {
+					_ = p.Stop()
+					return
+				}
+			}
+		}
+	}()
+}
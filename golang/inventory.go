@@ -0,0 +1,162 @@
+package httpplaybackproxy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ResourceMatcher selects a subset of an inventory's resources for the
+// mutation methods below. A nil field matches anything; a non-nil URL
+// matches as a regular expression against Resource.URL. The zero value
+// matches every resource.
+type ResourceMatcher struct {
+	Method *string
+	URL    *regexp.Regexp
+}
+
+// NewResourceMatcher builds a ResourceMatcher from a method (empty string
+// matches any method) and a URL regular expression (empty string matches
+// any URL).
+func NewResourceMatcher(method, urlPattern string) (ResourceMatcher, error) {
+	var m ResourceMatcher
+	if method != "" {
+		m.Method = &method
+	}
+	if urlPattern != "" {
+		re, err := regexp.Compile(urlPattern)
+		if err != nil {
+			return ResourceMatcher{}, fmt.Errorf("invalid URL pattern: %w", err)
+		}
+		m.URL = re
+	}
+	return m, nil
+}
+
+func (m ResourceMatcher) matches(r Resource) bool {
+	if m.Method != nil && r.Method != *m.Method {
+		return false
+	}
+	if m.URL != nil && !m.URL.MatchString(r.URL) {
+		return false
+	}
+	return true
+}
+
+// AddResource appends a resource to the inventory. If body is non-nil, it is
+// staged as inline base64 content; SaveInventory rewrites staged content to
+// a content-addressed file under contents/ so the on-disk layout stays
+// deterministic regardless of where the resource was spliced in. Callers
+// that already populated ContentFilePath, ContentUtf8, or ContentBase64 on r
+// directly may pass a nil body.
+func (inv *Inventory) AddResource(r Resource, body io.Reader) error {
+	if body != nil {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("failed to read resource body: %w", err)
+		}
+		encoded := base64.StdEncoding.EncodeToString(data)
+		r.ContentBase64 = &encoded
+	}
+	inv.Resources = append(inv.Resources, r)
+	return nil
+}
+
+// RemoveResource deletes every resource matching match and returns the
+// number removed. Content files on disk are left untouched, since a
+// content-addressed file (see SaveInventory) may still be shared by
+// another resource.
+func (inv *Inventory) RemoveResource(match ResourceMatcher) int {
+	kept := inv.Resources[:0]
+	removed := 0
+	for _, r := range inv.Resources {
+		if match.matches(r) {
+			removed++
+			continue
+		}
+		kept = append(kept, r)
+	}
+	inv.Resources = kept
+	return removed
+}
+
+// RewriteURL rewrites every resource URL (and EntryURL, if set) that starts
+// with from, replacing that prefix with to. This is the tool for splicing
+// two recordings captured against different hosts or ports into one
+// inventory, or for repointing a recording at a different environment.
+func (inv *Inventory) RewriteURL(from, to string) {
+	for i := range inv.Resources {
+		if strings.HasPrefix(inv.Resources[i].URL, from) {
+			inv.Resources[i].URL = to + strings.TrimPrefix(inv.Resources[i].URL, from)
+		}
+	}
+	if inv.EntryURL != nil && strings.HasPrefix(*inv.EntryURL, from) {
+		rewritten := to + strings.TrimPrefix(*inv.EntryURL, from)
+		inv.EntryURL = &rewritten
+	}
+}
+
+// SetHeader sets (overwriting any existing value) a response header on every
+// resource matching match. Use it to patch a CSP or similar header without
+// hand-editing JSON. It never removes a header; an empty value is stored as
+// an empty value.
+func (inv *Inventory) SetHeader(match ResourceMatcher, name, value string) {
+	for i := range inv.Resources {
+		if !match.matches(inv.Resources[i]) {
+			continue
+		}
+		if inv.Resources[i].RawHeaders == nil {
+			inv.Resources[i].RawHeaders = map[string]string{}
+		}
+		inv.Resources[i].RawHeaders[name] = value
+	}
+}
+
+// SetStatus overwrites the recorded status code on every resource matching
+// match.
+func (inv *Inventory) SetStatus(match ResourceMatcher, code int) {
+	for i := range inv.Resources {
+		if !match.matches(inv.Resources[i]) {
+			continue
+		}
+		status := uint16(code)
+		inv.Resources[i].StatusCode = &status
+	}
+}
+
+// MergeInventories appends every resource of src onto dst, reading each
+// resource's content (inline or file-backed, relative to srcDir) and
+// re-staging it through AddResource so SaveInventory re-homes it as a
+// content-addressed file under dst's directory. EntryURL and DeviceType are
+// left as dst's; src's are only used to fill them in if dst has none.
+func MergeInventories(dst *Inventory, src *Inventory, srcDir string) error {
+	for _, r := range src.Resources {
+		body, err := harResourceBody(srcDir, &r)
+		if err != nil {
+			return fmt.Errorf("failed to read content for %s %s: %w", r.Method, r.URL, err)
+		}
+		r.ContentFilePath = nil
+		r.ContentUtf8 = nil
+		r.ContentBase64 = nil
+
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		if err := dst.AddResource(r, reader); err != nil {
+			return fmt.Errorf("failed to add resource %s %s: %w", r.Method, r.URL, err)
+		}
+	}
+
+	if dst.EntryURL == nil {
+		dst.EntryURL = src.EntryURL
+	}
+	if dst.DeviceType == nil {
+		dst.DeviceType = src.DeviceType
+	}
+
+	return nil
+}
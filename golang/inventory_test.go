@@ -0,0 +1,190 @@
+package httpplaybackproxy
+
+import (
+	"strings"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestResourceMatcher(t *testing.T) {
+	getResource := Resource{Method: "GET", URL: "https://example.com/track.gif"}
+	postResource := Resource{Method: "POST", URL: "https://example.com/api"}
+
+	tests := []struct {
+		name       string
+		method     string
+		urlPattern string
+		resource   Resource
+		want       bool
+	}{
+		{"zero value matches anything", "", "", postResource, true},
+		{"method matches", "GET", "", getResource, true},
+		{"method mismatches", "POST", "", getResource, false},
+		{"url pattern matches", "", "track", getResource, true},
+		{"url pattern mismatches", "", "track", postResource, false},
+		{"method and url both required", "GET", "track", getResource, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewResourceMatcher(tt.method, tt.urlPattern)
+			if err != nil {
+				t.Fatalf("NewResourceMatcher failed: %v", err)
+			}
+			if got := m.matches(tt.resource); got != tt.want {
+				t.Errorf("matches(%+v) = %v, want %v", tt.resource, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewResourceMatcher("", "["); err == nil {
+		t.Error("NewResourceMatcher with an invalid regexp should return an error")
+	}
+}
+
+func TestAddResource(t *testing.T) {
+	inv := &Inventory{}
+	if err := inv.AddResource(Resource{Method: "GET", URL: "https://example.com/"}, strings.NewReader("hello")); err != nil {
+		t.Fatalf("AddResource failed: %v", err)
+	}
+
+	if len(inv.Resources) != 1 {
+		t.Fatalf("expected 1 resource, got %d", len(inv.Resources))
+	}
+	if inv.Resources[0].ContentBase64 == nil {
+		t.Fatal("expected body to be staged as inline base64 content")
+	}
+}
+
+func TestRemoveResource(t *testing.T) {
+	inv := &Inventory{
+		Resources: []Resource{
+			{Method: "GET", URL: "https://example.com/track.gif"},
+			{Method: "GET", URL: "https://example.com/app.js"},
+			{Method: "POST", URL: "https://example.com/api"},
+		},
+	}
+
+	m, err := NewResourceMatcher("", "track")
+	if err != nil {
+		t.Fatalf("NewResourceMatcher failed: %v", err)
+	}
+
+	removed := inv.RemoveResource(m)
+	if removed != 1 {
+		t.Fatalf("removed = %d, want 1", removed)
+	}
+	if len(inv.Resources) != 2 {
+		t.Fatalf("expected 2 remaining resources, got %d", len(inv.Resources))
+	}
+	for _, r := range inv.Resources {
+		if strings.Contains(r.URL, "track") {
+			t.Errorf("matched resource %q was not removed", r.URL)
+		}
+	}
+}
+
+func TestRewriteURL(t *testing.T) {
+	entryURL := "https://old.example.com/"
+	inv := &Inventory{
+		EntryURL: &entryURL,
+		Resources: []Resource{
+			{Method: "GET", URL: "https://old.example.com/"},
+			{Method: "GET", URL: "https://old.example.com/app.js"},
+			{Method: "GET", URL: "https://other.example.com/app.js"},
+		},
+	}
+
+	inv.RewriteURL("https://old.example.com", "https://new.example.com")
+
+	want := []string{
+		"https://new.example.com/",
+		"https://new.example.com/app.js",
+		"https://other.example.com/app.js",
+	}
+	for i, r := range inv.Resources {
+		if r.URL != want[i] {
+			t.Errorf("resource %d URL = %q, want %q", i, r.URL, want[i])
+		}
+	}
+	if *inv.EntryURL != "https://new.example.com/" {
+		t.Errorf("EntryURL = %q, want %q", *inv.EntryURL, "https://new.example.com/")
+	}
+}
+
+func TestSetHeaderAndSetStatus(t *testing.T) {
+	inv := &Inventory{
+		Resources: []Resource{
+			{Method: "GET", URL: "https://example.com/"},
+			{Method: "GET", URL: "https://example.com/api"},
+		},
+	}
+
+	m, err := NewResourceMatcher("", "api")
+	if err != nil {
+		t.Fatalf("NewResourceMatcher failed: %v", err)
+	}
+
+	inv.SetHeader(m, "X-Csp", "default-src 'self'")
+	if inv.Resources[0].RawHeaders != nil {
+		t.Error("SetHeader should not touch resources that don't match")
+	}
+	if got := inv.Resources[1].RawHeaders["X-Csp"]; got != "default-src 'self'" {
+		t.Errorf("RawHeaders[X-Csp] = %q, want %q", got, "default-src 'self'")
+	}
+
+	inv.SetStatus(m, 503)
+	if inv.Resources[0].StatusCode != nil {
+		t.Error("SetStatus should not touch resources that don't match")
+	}
+	if got := *inv.Resources[1].StatusCode; got != 503 {
+		t.Errorf("StatusCode = %d, want 503", got)
+	}
+}
+
+func TestMergeInventories(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+
+	src := &Inventory{
+		EntryURL: strPtr("https://example.com/"),
+		Resources: []Resource{
+			{Method: "GET", URL: "https://example.com/"},
+		},
+	}
+	if err := src.AddResource(Resource{Method: "GET", URL: "https://example.com/app.js"}, strings.NewReader("console.log(1)")); err != nil {
+		t.Fatalf("AddResource failed: %v", err)
+	}
+	if err := SaveInventory(GetInventoryPath(srcDir), src); err != nil {
+		t.Fatalf("SaveInventory(src) failed: %v", err)
+	}
+
+	dst := &Inventory{}
+	if err := MergeInventories(dst, src, srcDir); err != nil {
+		t.Fatalf("MergeInventories failed: %v", err)
+	}
+
+	if len(dst.Resources) != 2 {
+		t.Fatalf("expected 2 merged resources, got %d", len(dst.Resources))
+	}
+	if dst.EntryURL == nil || *dst.EntryURL != "https://example.com/" {
+		t.Errorf("EntryURL = %v, want filled in from src", dst.EntryURL)
+	}
+
+	if err := SaveInventory(GetInventoryPath(dstDir), dst); err != nil {
+		t.Fatalf("SaveInventory(dst) failed: %v", err)
+	}
+
+	reloaded, err := LoadInventory(GetInventoryPath(dstDir))
+	if err != nil {
+		t.Fatalf("LoadInventory failed: %v", err)
+	}
+	body, err := harResourceBody(dstDir, &reloaded.Resources[1])
+	if err != nil {
+		t.Fatalf("harResourceBody failed: %v", err)
+	}
+	if string(body) != "console.log(1)" {
+		t.Errorf("merged+resaved body = %q, want %q", body, "console.log(1)")
+	}
+}
@@ -0,0 +1,31 @@
+package httpplaybackproxy
+
+import "net"
+
+// listenerEnv is the fixed set of env vars attachListenerFiles sets on the
+// child to announce an inherited listener, modeled on (but not identical
+// to) systemd's socket-activation protocol: LISTEN_FDS counts the inherited
+// file descriptors and PLAYBACK_PROXY_LISTEN_FD/PLAYBACK_PROXY_CONTROL_LISTEN_FD
+// name which one is which. We can't fill in LISTEN_PID with the child's
+// actual pid, since Go only learns that from cmd.Start() after the env is
+// already fixed, so it's set to "0" as an explicit "unenforced" sentinel
+// rather than a value that would make a real systemd-style consumer
+// mistakenly trust it.
+const (
+	envListenFds       = "LISTEN_FDS"
+	envListenPid       = "LISTEN_PID"
+	envListenFd        = "PLAYBACK_PROXY_LISTEN_FD"
+	envControlListenFd = "PLAYBACK_PROXY_CONTROL_LISTEN_FD"
+)
+
+// listenerPort returns l's bound TCP port, or 0 if l is nil or not a TCP
+// listener.
+func listenerPort(l net.Listener) int {
+	if l == nil {
+		return 0
+	}
+	if addr, ok := l.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return 0
+}
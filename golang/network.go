@@ -0,0 +1,30 @@
+package httpplaybackproxy
+
+// NetworkProfile describes the network conditions the playback proxy should
+// simulate: it delays the first byte of each response to approximate RTT,
+// throttles body writes to the configured downlink, and (for ProfileCustom)
+// can simulate packet loss. Presets mirror the throttling profiles exposed
+// by Chrome DevTools so recordings replay consistently across machines.
+type NetworkProfile struct {
+	DownlinkKbps  int     // Downstream bandwidth, in kbps
+	UplinkKbps    int     // Upstream bandwidth, in kbps
+	RttMs         int     // Simulated round-trip time, in milliseconds
+	PacketLossPct float64 // Simulated packet loss, 0-100
+}
+
+// Preset network profiles, matching Chrome DevTools' throttling presets.
+var (
+	Profile3GSlow = NetworkProfile{DownlinkKbps: 400, UplinkKbps: 400, RttMs: 400}
+	Profile4G     = NetworkProfile{DownlinkKbps: 4000, UplinkKbps: 3000, RttMs: 170}
+	ProfileCable  = NetworkProfile{DownlinkKbps: 5000, UplinkKbps: 1000, RttMs: 28}
+)
+
+// ProfileCustom builds a NetworkProfile from explicit parameters.
+func ProfileCustom(downlinkKbps, uplinkKbps, rttMs int, packetLossPct float64) NetworkProfile {
+	return NetworkProfile{
+		DownlinkKbps:  downlinkKbps,
+		UplinkKbps:    uplinkKbps,
+		RttMs:         rttMs,
+		PacketLossPct: packetLossPct,
+	}
+}
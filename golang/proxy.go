@@ -3,8 +3,12 @@ package httpplaybackproxy
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -23,6 +27,110 @@ const (
 	ModePlayback  ProxyMode = "playback"
 )
 
+// LogStream identifies which child stream a LogLine came from.
+type LogStream string
+
+const (
+	LogStreamStdout LogStream = "stdout"
+	LogStreamStderr LogStream = "stderr"
+)
+
+// LogLine is a single line of output from the proxy child process. When the
+// line is a structured JSON log record (as the Rust binary emits when it
+// detects a non-interactive stdout), Level and Fields are populated from it
+// and Message holds the "msg"/"message" field; otherwise Message holds the
+// raw line verbatim and Level is empty.
+type LogLine struct {
+	Stream  LogStream
+	Time    time.Time
+	Level   string
+	Message string
+	Fields  map[string]interface{}
+	Raw     string
+}
+
+// LogHandler receives each line of output from the proxy's child process. If
+// set on RecordingOptions or PlaybackOptions, child output is routed here
+// instead of being forwarded to this process's own stdout/stderr, so a test
+// harness or agent embedding the proxy can capture logs without hijacking
+// its own output streams.
+type LogHandler func(LogLine)
+
+// portRegex extracts the bound port from the child process's startup
+// banner, which varies slightly between the recording and playback
+// binaries ("HTTPS MITM Proxy listening on ...", "Playback proxy
+// listening on ...", etc).
+var portRegex = regexp.MustCompile(`(?:HTTPS MITM |Playback |Recording )?[Pp]roxy listening on (?:127\.0\.0\.1|0\.0\.0\.0):(\d+)`)
+
+// parseLogLine wraps a raw line of child output in a LogLine, decoding it as
+// a structured JSON log record when possible.
+func parseLogLine(stream LogStream, raw string) LogLine {
+	line := LogLine{Stream: stream, Time: time.Now(), Raw: raw, Message: raw}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return line
+	}
+
+	if level, ok := parsed["level"].(string); ok {
+		line.Level = level
+		delete(parsed, "level")
+	}
+
+	msgKey := "msg"
+	if _, ok := parsed["message"]; ok {
+		msgKey = "message"
+	}
+	if msg, ok := parsed[msgKey].(string); ok {
+		line.Message = msg
+		delete(parsed, msgKey)
+	}
+
+	if len(parsed) > 0 {
+		line.Fields = parsed
+	}
+
+	return line
+}
+
+// streamStdout scans the child's stdout, forwarding each line to handler (or
+// this process's own stdout if handler is nil) and reporting the first port
+// number found in the startup banner on portChan.
+func streamStdout(stdout io.Reader, handler LogHandler, portChan chan<- int) {
+	scanner := bufio.NewScanner(stdout)
+	portFound := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if handler != nil {
+			handler(parseLogLine(LogStreamStdout, line))
+		} else {
+			fmt.Println(line) // Forward to stdout
+		}
+
+		if !portFound {
+			if matches := portRegex.FindStringSubmatch(line); len(matches) > 1 {
+				if port, err := strconv.Atoi(matches[1]); err == nil {
+					portChan <- port
+					portFound = true
+				}
+			}
+		}
+	}
+	if !portFound {
+		close(portChan) // Signal that no port was found
+	}
+}
+
+// streamStderr scans the child's stderr, forwarding each line to handler.
+// Only used when a LogHandler is set; otherwise stderr is connected directly
+// to this process's own stderr.
+func streamStderr(stderr io.Reader, handler LogHandler) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		handler(parseLogLine(LogStreamStderr, scanner.Text()))
+	}
+}
+
 // Proxy represents a running proxy instance
 type Proxy struct {
 	Mode         ProxyMode
@@ -35,6 +143,30 @@ type Proxy struct {
 	ctx          context.Context
 	cancel       context.CancelFunc
 	portMutex    sync.RWMutex
+
+	// Fields below support Run's supervisor mode: binaryPath/args let Run
+	// relaunch the same child the original Start* call built, cmdMutex
+	// guards cmd against concurrent access from Stop/IsRunning while Run
+	// swaps it out on restart, and the supervise* options/state track the
+	// restart policy and its outcome so callers can observe health.
+	binaryPath      string
+	args            []string
+	logHandler      LogHandler
+	startedAt       time.Time
+	cmdMutex        sync.Mutex
+	listener        net.Listener // Optional: caller-supplied proxy-port listener, reattached on restart
+	controlListener net.Listener // Optional: caller-supplied control-port listener, reattached on restart
+
+	supervise      bool
+	maxRestarts    int
+	minRunDuration time.Duration
+	backoffInitial time.Duration
+	backoffMax     time.Duration
+
+	stateMutex sync.RWMutex
+	restarts   int
+	lastExit   int
+	state      SupervisorState
 }
 
 // RecordingOptions holds options for starting a recording proxy
@@ -44,17 +176,174 @@ type RecordingOptions struct {
 	DeviceType   DeviceType // Optional: Device type (default: mobile)
 	InventoryDir string     // Optional: Inventory directory (default: ./inventory)
 	ControlPort  *int       // Optional: Control/management API port (enables HTTP shutdown)
+
+	// LogHandler, when set, receives every line the child process writes to
+	// stdout/stderr instead of it being forwarded to this process's own
+	// stdout/stderr.
+	LogHandler LogHandler
+
+	// Supervise enables Proxy.Run's restart-with-backoff loop. Without it,
+	// Run simply waits for the one child StartRecording spawned to exit.
+	Supervise bool
+
+	// MaxRestarts caps how many times Run will restart a crashed child
+	// before giving up. Zero means unlimited.
+	MaxRestarts int
+
+	// MinRunSeconds is the shortest a run must last to not count as a fast
+	// failure; two fast failures in a row make Run give up even if
+	// MaxRestarts hasn't been reached, since a process crash-looping this
+	// fast is unlikely to recover on its own.
+	MinRunSeconds int
+
+	// BackoffInitial and BackoffMax bound the exponential backoff Run
+	// applies between restarts. BackoffInitial defaults to 1 second;
+	// BackoffMax to no cap.
+	BackoffInitial time.Duration
+	BackoffMax     time.Duration
+
+	// IdleTimeout, when nonzero, makes the proxy stop itself once no request
+	// has been proxied for that long, polling /_stats on ControlPort every
+	// IdleTimeout/4 to notice. Requires ControlPort or ControlListener to be
+	// set; StartRecording returns an error otherwise. Aimed at ephemeral test setups and CI jobs
+	// that would otherwise leak the child process if the caller forgets to
+	// defer Stop.
+	IdleTimeout time.Duration
+
+	// Listener, when set, is handed to the child as an inherited file
+	// descriptor instead of having it bind Port itself, so the caller can
+	// reserve the port race-free (including with Port 0) before the child
+	// even starts. Port is ignored when Listener is set; use Listener.Addr()
+	// beforehand if the caller needs to know the port. Not supported on
+	// Windows.
+	Listener net.Listener
+
+	// ControlListener does the same for the control port, in place of
+	// ControlPort. Not supported on Windows.
+	ControlListener net.Listener
 }
 
+// MissPolicy controls how a playback proxy handles a request that has no
+// matching resource in the loaded inventory.
+type MissPolicy string
+
+const (
+	// MissError returns a 502 for unmatched requests (current/default behavior).
+	MissError MissPolicy = "error"
+	// MissPassthrough transparently forwards unmatched requests to the origin
+	// without touching the on-disk inventory.
+	MissPassthrough MissPolicy = "passthrough"
+	// MissRecord forwards unmatched requests to the origin and appends the
+	// response to the on-disk inventory, so a recording session can be
+	// resumed incrementally.
+	MissRecord MissPolicy = "record"
+)
+
 // PlaybackOptions holds options for starting a playback proxy
 type PlaybackOptions struct {
 	Port         int
 	InventoryDir string
 	ControlPort  *int // Optional: Control/management API port (enables HTTP shutdown)
+
+	// MissPolicy controls what happens on an inventory miss (default MissError).
+	MissPolicy MissPolicy
+
+	// UpstreamProxy is the proxy used to forward missed requests when
+	// MissPolicy is MissPassthrough or MissRecord. Takes precedence over
+	// UpstreamResolver.
+	UpstreamProxy *url.URL
+
+	// UpstreamResolver resolves the upstream proxy used to forward missed
+	// requests. Because the proxy itself runs as a child process rather than
+	// inside this Go process, it is evaluated once at startup against a
+	// synthetic request rather than per proxied request. Defaults to
+	// http.ProxyFromEnvironment when neither UpstreamProxy nor
+	// UpstreamResolver is set.
+	UpstreamResolver func(*http.Request) (*url.URL, error)
+
+	// NetworkProfile, when set, makes the playback proxy simulate the given
+	// network conditions (bandwidth, RTT, packet loss) while serving
+	// responses.
+	NetworkProfile *NetworkProfile
+
+	// RespectRecordedTTFB makes the playback proxy delay the first byte of
+	// each response by at least its recorded TtfbMs, even if that's slower
+	// than NetworkProfile.RttMs/2 would otherwise require.
+	RespectRecordedTTFB bool
+
+	// LogHandler, when set, receives every line the child process writes to
+	// stdout/stderr instead of it being forwarded to this process's own
+	// stdout/stderr.
+	LogHandler LogHandler
+
+	// Supervise enables Proxy.Run's restart-with-backoff loop. Without it,
+	// Run simply waits for the one child StartPlayback spawned to exit.
+	Supervise bool
+
+	// MaxRestarts caps how many times Run will restart a crashed child
+	// before giving up. Zero means unlimited.
+	MaxRestarts int
+
+	// MinRunSeconds is the shortest a run must last to not count as a fast
+	// failure; two fast failures in a row make Run give up even if
+	// MaxRestarts hasn't been reached, since a process crash-looping this
+	// fast is unlikely to recover on its own.
+	MinRunSeconds int
+
+	// BackoffInitial and BackoffMax bound the exponential backoff Run
+	// applies between restarts. BackoffInitial defaults to 1 second;
+	// BackoffMax to no cap.
+	BackoffInitial time.Duration
+	BackoffMax     time.Duration
+
+	// IdleTimeout, when nonzero, makes the proxy stop itself once no request
+	// has been proxied for that long, polling /_stats on ControlPort every
+	// IdleTimeout/4 to notice. Requires ControlPort or ControlListener to be
+	// set; StartPlayback returns an error otherwise. Aimed at ephemeral test setups and CI jobs
+	// that would otherwise leak the child process if the caller forgets to
+	// defer Stop.
+	IdleTimeout time.Duration
+
+	// Listener, when set, is handed to the child as an inherited file
+	// descriptor instead of having it bind Port itself, so the caller can
+	// reserve the port race-free (including with Port 0) before the child
+	// even starts. Port is ignored when Listener is set; use Listener.Addr()
+	// beforehand if the caller needs to know the port. Not supported on
+	// Windows.
+	Listener net.Listener
+
+	// ControlListener does the same for the control port, in place of
+	// ControlPort. Not supported on Windows.
+	ControlListener net.Listener
+}
+
+// resolveUpstreamProxy determines the upstream proxy to forward inventory
+// misses to, per the precedence documented on PlaybackOptions.
+func resolveUpstreamProxy(opts PlaybackOptions) (*url.URL, error) {
+	if opts.UpstreamProxy != nil {
+		return opts.UpstreamProxy, nil
+	}
+
+	resolver := opts.UpstreamResolver
+	if resolver == nil {
+		resolver = http.ProxyFromEnvironment
+	}
+
+	// The synthetic request must not target localhost or a loopback address:
+	// http.ProxyFromEnvironment never returns a proxy for those.
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resolver(req)
 }
 
 // StartRecording starts a recording proxy
 func StartRecording(opts RecordingOptions) (*Proxy, error) {
+	if opts.IdleTimeout > 0 && opts.ControlPort == nil && opts.ControlListener == nil {
+		return nil, fmt.Errorf("IdleTimeout requires ControlPort or ControlListener to be set")
+	}
+
 	if err := EnsureBinary(); err != nil {
 		return nil, fmt.Errorf("failed to ensure binary: %w", err)
 	}
@@ -76,8 +365,9 @@ func StartRecording(opts RecordingOptions) (*Proxy, error) {
 		args = append(args, opts.EntryURL)
 	}
 
-	// Add port option
-	if opts.Port != 0 {
+	// Add port option (skipped when a Listener is supplied: the child
+	// discovers its port from the inherited fd instead)
+	if opts.Port != 0 && opts.Listener == nil {
 		args = append(args, "--port", strconv.Itoa(opts.Port))
 	}
 
@@ -95,80 +385,117 @@ func StartRecording(opts RecordingOptions) (*Proxy, error) {
 	}
 	args = append(args, "--inventory", inventoryDir)
 
-	// Add control port if specified
-	if opts.ControlPort != nil {
+	// Add control port if specified (skipped when a ControlListener is
+	// supplied, same reasoning as --port above)
+	if opts.ControlPort != nil && opts.ControlListener == nil {
 		args = append(args, "--control-port", strconv.Itoa(*opts.ControlPort))
 	}
 
 	cmd := exec.CommandContext(ctx, binaryPath, args...)
 
+	var listenerEnv []string
+	var listenerFiles []*os.File
+	if opts.Listener != nil || opts.ControlListener != nil {
+		listenerEnv, listenerFiles, err = attachListenerFiles(cmd, opts.Listener, opts.ControlListener)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to attach listener: %w", err)
+		}
+		cmd.Env = append(os.Environ(), listenerEnv...)
+	}
+
 	// Capture stdout to extract actual port number
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
-	cmd.Stderr = os.Stderr
+	var stderr io.ReadCloser
+	if opts.LogHandler != nil {
+		stderr, err = cmd.StderrPipe()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+		}
+	} else {
+		cmd.Stderr = os.Stderr
+	}
 	setProcAttributes(cmd)
 
-	// Start the process
-	if err := cmd.Start(); err != nil {
+	// Start the process. cmd.Start() dups listenerFiles into the child, so
+	// the parent's copies are closed right after regardless of outcome -
+	// otherwise every start (and every supervised restart) leaks one fd per
+	// inherited listener in the parent.
+	startErr := cmd.Start()
+	for _, f := range listenerFiles {
+		f.Close()
+	}
+	if startErr != nil {
 		cancel()
-		return nil, fmt.Errorf("failed to start recording proxy: %w", err)
+		return nil, fmt.Errorf("failed to start recording proxy: %w", startErr)
 	}
 
 	// Store the actual values used (after defaults)
 	actualPort := opts.Port
-	if actualPort == 0 {
+	if opts.Listener != nil {
+		actualPort = listenerPort(opts.Listener)
+	} else if actualPort == 0 {
 		actualPort = 18080 // Default fallback
 	}
+	actualControlPort := opts.ControlPort
+	if opts.ControlListener != nil {
+		port := listenerPort(opts.ControlListener)
+		actualControlPort = &port
+	}
 	actualInventoryDir := inventoryDir
 	actualDeviceType := deviceType
 
 	proxy := &Proxy{
-		Mode:         ModeRecording,
-		Port:         actualPort,
-		ControlPort:  opts.ControlPort,
-		InventoryDir: actualInventoryDir,
-		EntryURL:     opts.EntryURL,
-		DeviceType:   actualDeviceType,
-		cmd:          cmd,
-		ctx:          ctx,
-		cancel:       cancel,
+		Mode:            ModeRecording,
+		Port:            actualPort,
+		ControlPort:     actualControlPort,
+		InventoryDir:    actualInventoryDir,
+		EntryURL:        opts.EntryURL,
+		DeviceType:      actualDeviceType,
+		cmd:             cmd,
+		ctx:             ctx,
+		cancel:          cancel,
+		binaryPath:      binaryPath,
+		args:            args,
+		logHandler:      opts.LogHandler,
+		startedAt:       time.Now(),
+		supervise:       opts.Supervise,
+		maxRestarts:     opts.MaxRestarts,
+		minRunDuration:  time.Duration(opts.MinRunSeconds) * time.Second,
+		backoffInitial:  opts.BackoffInitial,
+		backoffMax:      opts.BackoffMax,
+		state:           StateRunning,
+		listener:        opts.Listener,
+		controlListener: opts.ControlListener,
 	}
 
 	// Read stdout to find actual port number and forward output
 	portChan := make(chan int, 1)
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		// Regex that matches both "HTTPS MITM Proxy" and "Playback proxy"
-		portRegex := regexp.MustCompile(`(?:HTTPS MITM |Playback |Recording )?[Pp]roxy listening on (?:127\.0\.0\.1|0\.0\.0\.0):(\d+)`)
-		portFound := false
-		for scanner.Scan() {
-			line := scanner.Text()
-			fmt.Println(line) // Forward to stdout
-
-			// Extract port number from output
-			if !portFound {
-				if matches := portRegex.FindStringSubmatch(line); len(matches) > 1 {
-					if port, err := strconv.Atoi(matches[1]); err == nil {
-						portChan <- port
-						portFound = true
-					}
-				}
-			}
+	go streamStdout(stdout, opts.LogHandler, portChan)
+	if stderr != nil {
+		go streamStderr(stderr, opts.LogHandler)
+	}
+
+	if opts.Listener != nil {
+		// The port is already known from the listener we handed the child;
+		// no need to race its startup banner for it.
+	} else {
+		// Wait for actual port number (with timeout)
+		select {
+		case port := <-portChan:
+			proxy.Port = port
+		case <-time.After(5 * time.Second):
+			// Timeout - use default port
 		}
-		if !portFound {
-			close(portChan) // Signal that no port was found
-		}
-	}()
+	}
 
-	// Wait for actual port number (with timeout)
-	select {
-	case port := <-portChan:
-		proxy.Port = port
-	case <-time.After(5 * time.Second):
-		// Timeout - use default port
+	if opts.IdleTimeout > 0 {
+		startIdleMonitor(proxy, opts.IdleTimeout)
 	}
 
 	return proxy, nil
@@ -176,6 +503,10 @@ func StartRecording(opts RecordingOptions) (*Proxy, error) {
 
 // StartPlayback starts a playback proxy
 func StartPlayback(opts PlaybackOptions) (*Proxy, error) {
+	if opts.IdleTimeout > 0 && opts.ControlPort == nil && opts.ControlListener == nil {
+		return nil, fmt.Errorf("IdleTimeout requires ControlPort or ControlListener to be set")
+	}
+
 	if err := EnsureBinary(); err != nil {
 		return nil, fmt.Errorf("failed to ensure binary: %w", err)
 	}
@@ -205,76 +536,141 @@ func StartPlayback(opts PlaybackOptions) (*Proxy, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	args := []string{"playback"}
 
-	if port != 18080 {
+	if port != 18080 && opts.Listener == nil {
 		args = append(args, "--port", strconv.Itoa(port))
 	}
 
 	args = append(args, "--inventory", inventoryDir)
 
-	// Add control port if specified
-	if opts.ControlPort != nil {
+	// Add control port if specified (skipped when a ControlListener is
+	// supplied, same reasoning as --port above)
+	if opts.ControlPort != nil && opts.ControlListener == nil {
 		args = append(args, "--control-port", strconv.Itoa(*opts.ControlPort))
 	}
 
+	// Add miss policy and upstream proxy, if forwarding on inventory misses
+	if opts.MissPolicy != "" && opts.MissPolicy != MissError {
+		args = append(args, "--miss-policy", string(opts.MissPolicy))
+
+		upstream, err := resolveUpstreamProxy(opts)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to resolve upstream proxy: %w", err)
+		}
+		if upstream != nil {
+			args = append(args, "--upstream-proxy", upstream.String())
+		}
+	}
+
+	// Add network condition simulation, if requested
+	if opts.NetworkProfile != nil {
+		args = append(args,
+			"--network-downlink-kbps", strconv.Itoa(opts.NetworkProfile.DownlinkKbps),
+			"--network-uplink-kbps", strconv.Itoa(opts.NetworkProfile.UplinkKbps),
+			"--network-rtt-ms", strconv.Itoa(opts.NetworkProfile.RttMs),
+		)
+		if opts.NetworkProfile.PacketLossPct > 0 {
+			args = append(args, "--network-packet-loss-pct", strconv.FormatFloat(opts.NetworkProfile.PacketLossPct, 'f', -1, 64))
+		}
+	}
+	if opts.RespectRecordedTTFB {
+		args = append(args, "--respect-recorded-ttfb")
+	}
+
 	cmd := exec.CommandContext(ctx, binaryPath, args...)
 
+	var listenerEnv []string
+	var listenerFiles []*os.File
+	if opts.Listener != nil || opts.ControlListener != nil {
+		listenerEnv, listenerFiles, err = attachListenerFiles(cmd, opts.Listener, opts.ControlListener)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to attach listener: %w", err)
+		}
+		cmd.Env = append(os.Environ(), listenerEnv...)
+	}
+
 	// Capture stdout to extract actual port number
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
-	cmd.Stderr = os.Stderr
+	var stderr io.ReadCloser
+	if opts.LogHandler != nil {
+		stderr, err = cmd.StderrPipe()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+		}
+	} else {
+		cmd.Stderr = os.Stderr
+	}
 	setProcAttributes(cmd)
 
-	// Start the process
-	if err := cmd.Start(); err != nil {
+	// Start the process. cmd.Start() dups listenerFiles into the child, so
+	// the parent's copies are closed right after regardless of outcome -
+	// otherwise every start (and every supervised restart) leaks one fd per
+	// inherited listener in the parent.
+	startErr := cmd.Start()
+	for _, f := range listenerFiles {
+		f.Close()
+	}
+	if startErr != nil {
 		cancel()
-		return nil, fmt.Errorf("failed to start playback proxy: %w", err)
+		return nil, fmt.Errorf("failed to start playback proxy: %w", startErr)
+	}
+
+	if opts.Listener != nil {
+		port = listenerPort(opts.Listener)
+	}
+	actualControlPort := opts.ControlPort
+	if opts.ControlListener != nil {
+		controlPort := listenerPort(opts.ControlListener)
+		actualControlPort = &controlPort
 	}
 
 	proxy := &Proxy{
-		Mode:         ModePlayback,
-		Port:         port,
-		ControlPort:  opts.ControlPort,
-		InventoryDir: inventoryDir,
-		cmd:          cmd,
-		ctx:          ctx,
-		cancel:       cancel,
+		Mode:            ModePlayback,
+		Port:            port,
+		ControlPort:     actualControlPort,
+		InventoryDir:    inventoryDir,
+		cmd:             cmd,
+		ctx:             ctx,
+		cancel:          cancel,
+		binaryPath:      binaryPath,
+		args:            args,
+		logHandler:      opts.LogHandler,
+		startedAt:       time.Now(),
+		supervise:       opts.Supervise,
+		maxRestarts:     opts.MaxRestarts,
+		minRunDuration:  time.Duration(opts.MinRunSeconds) * time.Second,
+		backoffInitial:  opts.BackoffInitial,
+		backoffMax:      opts.BackoffMax,
+		state:           StateRunning,
+		listener:        opts.Listener,
+		controlListener: opts.ControlListener,
 	}
 
 	// Read stdout to find actual port number and forward output
 	portChan := make(chan int, 1)
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		// Regex that matches both "HTTPS MITM Proxy" and "Playback proxy"
-		portRegex := regexp.MustCompile(`(?:HTTPS MITM |Playback |Recording )?[Pp]roxy listening on (?:127\.0\.0\.1|0\.0\.0\.0):(\d+)`)
-		portFound := false
-		for scanner.Scan() {
-			line := scanner.Text()
-			fmt.Println(line) // Forward to stdout
-
-			// Extract port number from output
-			if !portFound {
-				if matches := portRegex.FindStringSubmatch(line); len(matches) > 1 {
-					if port, err := strconv.Atoi(matches[1]); err == nil {
-						portChan <- port
-						portFound = true
-					}
-				}
-			}
+	go streamStdout(stdout, opts.LogHandler, portChan)
+	if stderr != nil {
+		go streamStderr(stderr, opts.LogHandler)
+	}
+
+	if opts.Listener == nil {
+		// Wait for actual port number (with timeout)
+		select {
+		case port := <-portChan:
+			proxy.Port = port
+		case <-time.After(5 * time.Second):
+			// Timeout - use default port
 		}
-		if !portFound {
-			close(portChan) // Signal that no port was found
-		}
-	}()
+	}
 
-	// Wait for actual port number (with timeout)
-	select {
-	case port := <-portChan:
-		proxy.Port = port
-	case <-time.After(5 * time.Second):
-		// Timeout - use default port
+	if opts.IdleTimeout > 0 {
+		startIdleMonitor(proxy, opts.IdleTimeout)
 	}
 
 	return proxy, nil
@@ -344,26 +740,10 @@ func (p *Proxy) waitForExit() error {
 
 	select {
 	case err := <-done:
-		if err != nil {
-			// Exit code 130 is expected for SIGINT, -1 for signals, 0 for success
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				exitCode := exitErr.ExitCode()
-				// Windows: 0xc000013a (STATUS_CONTROL_C_EXIT) = 3221225786 or -1073741510
-				// Unix: 130 (128 + SIGINT=2) or -1 for signals
-				if exitCode == 0 || exitCode == 130 || exitCode == -1 ||
-					exitCode == 3221225786 || exitCode == -1073741510 {
-					// Normal exit codes for graceful shutdown
-					return nil
-				}
-			}
-			// For other signal-related errors, also treat as success
-			if err.Error() == "signal: interrupt" {
-				return nil
-			}
-			return fmt.Errorf("proxy exited with error: %w", err)
+		if isGracefulExitErr(err) {
+			return nil
 		}
-		// Exit code 0 - success
-		return nil
+		return fmt.Errorf("proxy exited with error: %w", err)
 	case <-time.After(10 * time.Second):
 		// Force kill if graceful shutdown takes too long
 		p.cancel()
@@ -372,6 +752,36 @@ func (p *Proxy) waitForExit() error {
 	}
 }
 
+// isGracefulExitErr reports whether err (as returned by exec.Cmd.Wait())
+// represents a clean shutdown rather than a crash: exit code 0, the
+// SIGINT/SIGTERM-driven codes 130/-1, their Windows CTRL_BREAK equivalents,
+// or the "signal: interrupt" error some platforms report instead of an
+// *exec.ExitError.
+func isGracefulExitErr(err error) bool {
+	if err == nil {
+		return true
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		switch exitErr.ExitCode() {
+		case 0, 130, -1, 3221225786, -1073741510:
+			return true
+		}
+	}
+	return err.Error() == "signal: interrupt"
+}
+
+// processExitCode extracts the numeric exit code from err (as returned by
+// exec.Cmd.Wait()), returning -1 if err doesn't carry one (e.g. a signal).
+func processExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
 // IsRunning checks if the proxy is still running
 func (p *Proxy) IsRunning() bool {
 	if p.cmd == nil || p.cmd.Process == nil {
@@ -3,6 +3,8 @@
 package httpplaybackproxy
 
 import (
+	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"syscall"
@@ -30,3 +32,61 @@ func isProcessRunning(proc *os.Process) bool {
 	err := proc.Signal(syscall.Signal(0))
 	return err == nil
 }
+
+// filer is satisfied by *net.TCPListener (and any other net.Listener that
+// exposes its underlying fd), letting attachListenerFiles dup it onto the
+// child without depending on a concrete listener type.
+type filer interface {
+	File() (*os.File, error)
+}
+
+// attachListenerFiles dups proxyListener and controlListener (either may be
+// nil) onto cmd as inherited file descriptors and sets the env vars the
+// child uses to discover them, implementing the socket-activation handoff
+// described on RecordingOptions.Listener/PlaybackOptions.Listener. Passing
+// the listener this way, rather than a --port flag, is what makes port 0
+// race-free and lets the supervisor hold the port open across a restart.
+//
+// It returns the dup'd *os.File(s) it appended to cmd.ExtraFiles alongside
+// env; cmd.Start() dups them again for the child, so the caller must close
+// these once Start() returns (success or failure) to avoid leaking one fd
+// per listener in the parent on every start and every supervised restart.
+func attachListenerFiles(cmd *exec.Cmd, proxyListener, controlListener net.Listener) (env []string, extraFiles []*os.File, err error) {
+	fd := 3 // fd 0-2 are stdin/stdout/stderr; ExtraFiles start at 3
+
+	if proxyListener != nil {
+		fl, ok := proxyListener.(filer)
+		if !ok {
+			return nil, nil, fmt.Errorf("proxy listener of type %T does not support socket activation (must expose File())", proxyListener)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get file for proxy listener: %w", err)
+		}
+		cmd.ExtraFiles = append(cmd.ExtraFiles, f)
+		extraFiles = append(extraFiles, f)
+		env = append(env, fmt.Sprintf("%s=%d", envListenFd, fd))
+		fd++
+	}
+
+	if controlListener != nil {
+		fl, ok := controlListener.(filer)
+		if !ok {
+			return nil, nil, fmt.Errorf("control listener of type %T does not support socket activation (must expose File())", controlListener)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get file for control listener: %w", err)
+		}
+		cmd.ExtraFiles = append(cmd.ExtraFiles, f)
+		extraFiles = append(extraFiles, f)
+		env = append(env, fmt.Sprintf("%s=%d", envControlListenFd, fd))
+		fd++
+	}
+
+	env = append(env,
+		fmt.Sprintf("%s=%d", envListenFds, len(cmd.ExtraFiles)),
+		fmt.Sprintf("%s=0", envListenPid),
+	)
+	return env, extraFiles, nil
+}
@@ -3,6 +3,8 @@
 package httpplaybackproxy
 
 import (
+	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"syscall"
@@ -81,3 +83,16 @@ func isProcessRunning(proc *os.Process) bool {
 	procCloseHandle.Call(handle)
 	return true
 }
+
+// attachListenerFiles fails on Windows whenever a listener is supplied (with
+// both nil it returns nothing and no error): sockets don't survive the
+// handle-inheritance path Go's os/exec uses for ExtraFiles the way plain
+// pipes do, so RecordingOptions.Listener/PlaybackOptions.Listener aren't
+// supported here. Callers on Windows should leave Listener/ControlListener
+// nil and let the proxy bind its own port as before.
+func attachListenerFiles(cmd *exec.Cmd, proxyListener, controlListener net.Listener) (env []string, extraFiles []*os.File, err error) {
+	if proxyListener != nil || controlListener != nil {
+		return nil, nil, fmt.Errorf("socket-activated listeners are not supported on Windows")
+	}
+	return nil, nil, nil
+}
@@ -0,0 +1,241 @@
+package httpplaybackproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// SupervisorState reports Run's current view of the child process's health.
+type SupervisorState string
+
+const (
+	StateRunning    SupervisorState = "running"
+	StateRestarting SupervisorState = "restarting"
+	StateStopped    SupervisorState = "stopped"
+	StateFailed     SupervisorState = "failed"
+)
+
+// Restarts returns how many times Run has restarted the child process.
+func (p *Proxy) Restarts() int {
+	p.stateMutex.RLock()
+	defer p.stateMutex.RUnlock()
+	return p.restarts
+}
+
+// LastExit returns the exit code of the most recently finished run of the
+// child process.
+func (p *Proxy) LastExit() int {
+	p.stateMutex.RLock()
+	defer p.stateMutex.RUnlock()
+	return p.lastExit
+}
+
+// State returns Run's current view of the child process's health.
+func (p *Proxy) State() SupervisorState {
+	p.stateMutex.RLock()
+	defer p.stateMutex.RUnlock()
+	return p.state
+}
+
+func (p *Proxy) setState(s SupervisorState) {
+	p.stateMutex.Lock()
+	p.state = s
+	p.stateMutex.Unlock()
+}
+
+func (p *Proxy) setLastExit(code int) {
+	p.stateMutex.Lock()
+	p.lastExit = code
+	p.stateMutex.Unlock()
+}
+
+func (p *Proxy) incrementRestarts() int {
+	p.stateMutex.Lock()
+	p.restarts++
+	n := p.restarts
+	p.stateMutex.Unlock()
+	return n
+}
+
+// Run blocks, monitoring the child process this Proxy was started with.
+//
+// Without Supervise set on the originating RecordingOptions/PlaybackOptions,
+// Run simply waits for that one child to exit, returning nil on a graceful
+// exit and an error otherwise (same classification as Stop).
+//
+// With Supervise set, a non-graceful exit restarts the child with
+// exponential backoff (starting at BackoffInitial, capped at BackoffMax),
+// up to MaxRestarts times; restarts re-detect the port from the child's
+// startup banner and fail if it doesn't match the port callers are already
+// using. Two exits in a row shorter than MinRunSeconds — graceful or not —
+// are treated as a fatal misconfiguration rather than something a restart
+// can fix, and Run gives up even if MaxRestarts hasn't been reached.
+//
+// Run checks ctx only when the current child exits and while waiting out a
+// backoff: cancelling ctx makes Run return nil at the next of those points
+// without restarting, but does not itself terminate a running child. Stop
+// terminates the current child; Run then returns nil if that exit is
+// graceful and the run lasted at least MinRunSeconds, and otherwise handles
+// it like any other exit.
+func (p *Proxy) Run(ctx context.Context) error {
+	if !p.supervise {
+		code, _, waitErr := p.waitCurrent()
+		p.setLastExit(code)
+		if isGracefulExitErr(waitErr) {
+			p.setState(StateStopped)
+			return nil
+		}
+		p.setState(StateFailed)
+		return fmt.Errorf("proxy exited with error: %w", waitErr)
+	}
+
+	backoff := p.backoffInitial
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	consecutiveFastExits := 0
+
+	for {
+		code, runDuration, waitErr := p.waitCurrent()
+		p.setLastExit(code)
+
+		if ctx.Err() != nil {
+			p.setState(StateStopped)
+			return nil
+		}
+
+		graceful := isGracefulExitErr(waitErr)
+		fast := p.minRunDuration > 0 && runDuration < p.minRunDuration
+
+		if graceful && !fast {
+			p.setState(StateStopped)
+			return nil
+		}
+
+		if fast {
+			consecutiveFastExits++
+			if consecutiveFastExits >= 2 {
+				p.setState(StateFailed)
+				return fmt.Errorf("proxy exited within %s of starting twice in a row, giving up", p.minRunDuration)
+			}
+		} else {
+			consecutiveFastExits = 0
+		}
+
+		restarts := p.incrementRestarts()
+		if p.maxRestarts > 0 && restarts > p.maxRestarts {
+			p.setState(StateFailed)
+			return fmt.Errorf("proxy exceeded max restarts (%d)", p.maxRestarts)
+		}
+
+		p.setState(StateRestarting)
+		select {
+		case <-ctx.Done():
+			p.setState(StateStopped)
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if p.backoffMax > 0 && backoff > p.backoffMax {
+			backoff = p.backoffMax
+		}
+
+		if err := p.restart(); err != nil {
+			p.setState(StateFailed)
+			return fmt.Errorf("failed to restart proxy: %w", err)
+		}
+		p.setState(StateRunning)
+	}
+}
+
+// waitCurrent waits for the Proxy's current child process to exit and
+// reports its exit code, how long it ran, and the raw error from Wait (nil
+// on a clean exit).
+func (p *Proxy) waitCurrent() (code int, runDuration time.Duration, err error) {
+	p.cmdMutex.Lock()
+	cmd := p.cmd
+	started := p.startedAt
+	p.cmdMutex.Unlock()
+
+	err = cmd.Wait()
+	return processExitCode(err), time.Since(started), err
+}
+
+// restart relaunches the proxy's child process with the binary and
+// arguments the original Start* call built, tied to the Proxy's own
+// lifetime context so Stop/cancel still terminates it. It re-detects the
+// bound port from the startup banner and fails (without swapping in the new
+// process) if that port doesn't match the one callers already have, since a
+// silently different port would strand anyone pointed at the old one.
+func (p *Proxy) restart() error {
+	cmd := exec.CommandContext(p.ctx, p.binaryPath, p.args...)
+
+	var listenerFiles []*os.File
+	if p.listener != nil || p.controlListener != nil {
+		env, files, err := attachListenerFiles(cmd, p.listener, p.controlListener)
+		if err != nil {
+			return fmt.Errorf("failed to reattach listener: %w", err)
+		}
+		cmd.Env = append(os.Environ(), env...)
+		listenerFiles = files
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	var stderr io.ReadCloser
+	if p.logHandler != nil {
+		stderr, err = cmd.StderrPipe()
+		if err != nil {
+			return fmt.Errorf("failed to create stderr pipe: %w", err)
+		}
+	} else {
+		cmd.Stderr = os.Stderr
+	}
+	setProcAttributes(cmd)
+
+	// cmd.Start() dups listenerFiles into the child, so the parent's copies
+	// are closed right after regardless of outcome - otherwise every
+	// restart leaks one fd per inherited listener in the parent.
+	startErr := cmd.Start()
+	for _, f := range listenerFiles {
+		f.Close()
+	}
+	if startErr != nil {
+		return fmt.Errorf("failed to restart proxy: %w", startErr)
+	}
+
+	portChan := make(chan int, 1)
+	go streamStdout(stdout, p.logHandler, portChan)
+	if stderr != nil {
+		go streamStderr(stderr, p.logHandler)
+	}
+
+	// With a caller-supplied Listener, the child never prints a startup
+	// banner with a port to re-detect (it's handed the bound listener
+	// directly), so there's nothing to compare against p.Port here.
+	if p.listener == nil {
+		select {
+		case port := <-portChan:
+			if port != p.Port {
+				_ = cmd.Process.Kill()
+				_ = cmd.Wait()
+				return fmt.Errorf("restarted proxy bound port %d, expected %d", port, p.Port)
+			}
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	p.cmdMutex.Lock()
+	p.cmd = cmd
+	p.startedAt = time.Now()
+	p.cmdMutex.Unlock()
+
+	return nil
+}
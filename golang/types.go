@@ -1,7 +1,10 @@
 package httpplaybackproxy
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -65,8 +68,41 @@ func LoadInventory(inventoryPath string) (*Inventory, error) {
 	return &inventory, nil
 }
 
-// SaveInventory saves an inventory to a JSON file
+// SaveInventory saves an inventory to a JSON file. Any resource carrying
+// inline content (ContentUtf8 or ContentBase64, as staged by
+// Inventory.AddResource or MergeInventories) is first rewritten to a file
+// under inventoryDir/contents, named by a hash of its method, URL, and body
+// so the same resource always lands at the same path and repeated saves
+// produce minimal diffs.
 func SaveInventory(inventoryPath string, inventory *Inventory) error {
+	inventoryDir := filepath.Dir(inventoryPath)
+
+	for i := range inventory.Resources {
+		r := &inventory.Resources[i]
+		body, staged, err := stagedResourceBody(*r)
+		if err != nil {
+			return fmt.Errorf("failed to decode inline content for %s %s: %w", r.Method, r.URL, err)
+		}
+		if !staged {
+			continue
+		}
+
+		contentsDir := filepath.Join(inventoryDir, "contents")
+		if err := os.MkdirAll(contentsDir, 0755); err != nil {
+			return fmt.Errorf("failed to create contents directory: %w", err)
+		}
+
+		fileName := fmt.Sprintf("%x", sha256.Sum256([]byte(r.Method+":"+r.URL+":"+string(body))))[:16]
+		contentPath := filepath.Join("contents", fileName)
+		if err := os.WriteFile(filepath.Join(inventoryDir, contentPath), body, 0644); err != nil {
+			return fmt.Errorf("failed to write content file for %s %s: %w", r.Method, r.URL, err)
+		}
+
+		r.ContentFilePath = &contentPath
+		r.ContentUtf8 = nil
+		r.ContentBase64 = nil
+	}
+
 	data, err := json.MarshalIndent(inventory, "", "  ")
 	if err != nil {
 		return err
@@ -75,6 +111,23 @@ func SaveInventory(inventoryPath string, inventory *Inventory) error {
 	return os.WriteFile(inventoryPath, data, 0644)
 }
 
+// stagedResourceBody returns the decoded inline body staged on a resource
+// (if any) that still needs to be written out as a content file.
+func stagedResourceBody(r Resource) (body []byte, staged bool, err error) {
+	switch {
+	case r.ContentUtf8 != nil:
+		return []byte(*r.ContentUtf8), true, nil
+	case r.ContentBase64 != nil:
+		data, err := base64.StdEncoding.DecodeString(*r.ContentBase64)
+		if err != nil {
+			return nil, false, err
+		}
+		return data, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
 // GetResourceContentPath returns the full path to a resource's content file
 // given the inventory directory and the resource
 func GetResourceContentPath(inventoryDir string, resource *Resource) string {